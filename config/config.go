@@ -3,17 +3,22 @@ package config
 import (
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"syblog/logger"
+	"sync"
 
 	"github.com/pelletier/go-toml/v2"
 	"github.com/pkg/errors"
 )
 
 type Config struct {
-	SY   SYConfig   `toml:"siyuan"`
-	Hugo HugoConfig `toml:"hugo"`
-	SSH  SSHConfig  `toml:"ssh"`
+	SY      SYConfig              `toml:"siyuan"`
+	Hugo    HugoConfig            `toml:"hugo"`
+	SSH     SSHConfig             `toml:"ssh"`
+	Attrs   map[string]AttrConfig `toml:"attrs"`
+	Publish PublishConfig         `toml:"publish"`
 }
 
 type SYConfig struct {
@@ -21,12 +26,63 @@ type SYConfig struct {
 	APIToken      string `toml:"apiToken"`
 	WorkspacePath string `toml:"workspacePath"`
 	AssetsPath    string `toml:"-"`
+	EmojisPath    string `toml:"-"`
 }
 
 type HugoConfig struct {
-	ExcutePath  string `toml:"excutePath"`
-	BlogPath    string `toml:"blogPath"`
-	SectionName string `toml:"sectionName"`
+	Target              string            `toml:"target"`
+	ExcutePath          string            `toml:"excutePath"`
+	BlogPath            string            `toml:"blogPath"`
+	SectionName         string            `toml:"sectionName"`
+	Title               string            `toml:"title"`
+	Hierarchy           bool              `toml:"hierarchy"`
+	Sections            map[string]string `toml:"sections"`
+	Dialect             string            `toml:"dialect"`
+	ToC                 string            `toml:"toc"`
+	HeadingAnchor       string            `toml:"headingAnchor"`
+	HeadingAnchorPrefix string            `toml:"headingAnchorPrefix"`
+	BlockAttributes     bool              `toml:"blockAttributes"`
+	Widget              string            `toml:"widget"`
+	BilibiliShortcode   string            `toml:"bilibiliShortcode"`
+	FrontMatter         string            `toml:"frontMatter"`
+	Backlinks           string            `toml:"backlinks"`
+	BacklinksInline     bool              `toml:"backlinksInline"`
+	BacklinksTemplate   string            `toml:"backlinksTemplate"`
+	BacklinksContext    bool              `toml:"backlinksContext"`
+	Mentions            bool              `toml:"mentions"`
+	MentionsMinLength   int               `toml:"mentionsMinLength"`
+	Graph               string            `toml:"graph"`
+	Search              bool              `toml:"search"`
+	SearchInverted      bool              `toml:"searchInverted"`
+	Summary             bool              `toml:"summary"`
+	SummaryLength       int               `toml:"summaryLength"`
+	SummaryMore         bool              `toml:"summaryMore"`
+	Related             bool              `toml:"related"`
+	RelatedCount        int               `toml:"relatedCount"`
+	Series              bool              `toml:"series"`
+	Preview             bool              `toml:"preview"`
+}
+
+// AttrConfig 描述了 custom-sn-* 属性输出到 Front Matter 时的类型和位置
+type AttrConfig struct {
+	Type      string `toml:"type"`      // bool、int、float、list、date、string，默认为string
+	Separator string `toml:"separator"` // list类型的分隔符，默认为英文逗号
+	Layout    string `toml:"layout"`    // date类型的时间格式，默认依次尝试常见格式
+	Key       string `toml:"key"`       // 输出的键，使用.分隔表示嵌套表，如cover.image，默认为属性名
+}
+
+// PublishConfig 描述了除 custom-publish=1 以外需要发布的文档，满足任一 include 且不满足任何 exclude 的文档会被发布
+type PublishConfig struct {
+	Include []Selector `toml:"include"`
+	Exclude []Selector `toml:"exclude"`
+}
+
+// Selector 描述了一组文档，设置的各项需要同时满足
+type Selector struct {
+	Box   string `toml:"box"`   // 笔记本ID或名称
+	HPath string `toml:"hpath"` // 文档路径，包括该文档及其所有子文档，如：/技术/Go
+	Tag   string `toml:"tag"`   // 文档标签
+	SQL   string `toml:"sql"`   // blocks 表的 SQL 条件，如：created > '20220101000000'
 }
 
 type SSHConfig struct {
@@ -37,24 +93,37 @@ type SSHConfig struct {
 	SitePath string `toml:"sitePath"`
 }
 
-var cfg Config
+var (
+	cfg      Config
+	loadOnce sync.Once
+)
 
+// GetConfig 返回配置，首次调用时读取配置文件
 func GetConfig() Config {
+	loadOnce.Do(load)
 	return cfg
 }
 
-func init() {
-	file, err := os.Open("config.toml")
+// load 读取配置文件并设置默认值，配置文件默认为当前目录下的config.toml，可通过环境变量SYBLOG_CONFIG指定
+func load() {
+	path := os.Getenv("SYBLOG_CONFIG")
+	if path == "" {
+		path = "config.toml"
+	}
+	file, err := os.Open(path)
 	if err != nil {
-		logger.Fatalf("%+v", errors.Wrap(err, "配置文件config.toml打开失败"))
+		logger.Fatalf("%+v", errors.Wrap(err, "配置文件"+path+"打开失败"))
 	}
+	defer file.Close()
 	bs, err := ioutil.ReadAll(file)
 	if err != nil {
-		logger.Fatalf("%+v", errors.Wrap(err, "配置文件config.toml读取失败"))
+		logger.Fatalf("%+v", errors.Wrap(err, "配置文件"+path+"读取失败"))
 	}
+	// 未配置时保持在文章末尾输出反链列表
+	cfg.Hugo.BacklinksInline = true
 	err = toml.Unmarshal(bs, &cfg)
 	if err != nil {
-		logger.Fatalf("%+v", errors.Wrap(err, "配置文件config.toml解析失败"))
+		logger.Fatalf("%+v", errors.Wrap(err, "配置文件"+path+"解析失败"))
 	}
 
 	if cfg.SY.APIURL == "" {
@@ -72,6 +141,44 @@ func init() {
 	if cfg.Hugo.SectionName == "" {
 		cfg.Hugo.SectionName = "notes"
 	}
+	if !ValidSection(cfg.Hugo.SectionName) {
+		logger.Fatalf("%+v", errors.Errorf("sectionName配置不合法：%s", cfg.Hugo.SectionName))
+	}
+	for box, s := range cfg.Hugo.Sections {
+		if !ValidSection(s) {
+			logger.Fatalf("%+v", errors.Errorf("sections配置中%s的section不合法：%s", box, s))
+		}
+	}
+
+	if cfg.Hugo.MentionsMinLength <= 0 {
+		cfg.Hugo.MentionsMinLength = 3
+	}
+
+	if cfg.Hugo.SummaryLength <= 0 {
+		cfg.Hugo.SummaryLength = 120
+	}
+
+	if cfg.Hugo.RelatedCount <= 0 {
+		cfg.Hugo.RelatedCount = 5
+	}
+
+	if cfg.Attrs == nil {
+		cfg.Attrs = make(map[string]AttrConfig)
+	}
+	for _, k := range []string{"date", "lastmod"} {
+		if _, ok := cfg.Attrs[k]; !ok {
+			cfg.Attrs[k] = AttrConfig{Type: "date"}
+		}
+	}
 
 	cfg.SY.AssetsPath = filepath.Join(cfg.SY.WorkspacePath, "data", "assets")
+	cfg.SY.EmojisPath = filepath.Join(cfg.SY.WorkspacePath, "data", "emojis")
+}
+
+// ValidSection 判断 section 是否为内容目录下的相对路径：不能为空、.、绝对路径或以 .. 开头，发布前会清理 section 目录
+func ValidSection(s string) bool {
+	if s == "" || s == "." || strings.HasPrefix(s, "..") || strings.ContainsAny(s, "\\:") {
+		return false
+	}
+	return !path.IsAbs(s) && !filepath.IsAbs(s) && path.Clean(s) == s
 }
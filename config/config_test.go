@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestValidSection(t *testing.T) {
+	tests := []struct {
+		section string
+		want    bool
+	}{
+		{"notes", true},
+		{"posts/til", true},
+		{"笔记", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../x", false},
+		{"/etc", false},
+		{"a/../b", false},
+		{"a//b", false},
+		{"a\\b", false},
+		{"C:x", false},
+	}
+	for _, tt := range tests {
+		if got := ValidSection(tt.section); got != tt.want {
+			t.Errorf("ValidSection(%q) = %v, want %v", tt.section, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"path/filepath"
+	"syblog/config"
+	"syblog/render"
+	"syblog/service"
+)
+
+// Astro 将文章输出为内容集合 src/content/<section>/<标题>/index.md，按文档树输出时标题前为各级上级文档的标题，
+// 资源放在 public 中与文章地址相同的目录
+type Astro struct{}
+
+func (a *Astro) Name() string {
+	return "Astro"
+}
+
+func (a *Astro) collectionPath(section string) string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "src", "content", section)
+}
+
+// assetsRoot 返回资源的根目录，public 中的文件不经处理直接发布
+func (a *Astro) assetsRoot(section string) string {
+	return filepath.Join(a.StaticPath(), section)
+}
+
+func (a *Astro) CleanPaths(articles *service.ArticleList) []string {
+	var ret []string
+	for _, s := range articles.Sections() {
+		ret = append(append(ret, sectionCleanPaths(a.collectionPath(s))...), ownedPath(a.assetsRoot(s)))
+	}
+	return ret
+}
+
+// Prepare 创建各个 section 的资源根目录并放置标记文件，之后的发布只会清理带有标记文件的目录
+func (a *Astro) Prepare(articles *service.ArticleList) error {
+	for _, s := range articles.Sections() {
+		if err := markSection(a.collectionPath(s)); err != nil {
+			return err
+		}
+		if err := markOwned(a.assetsRoot(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Astro) ArticlePath(article *service.Article) string {
+	return filepath.Join(append(append([]string{a.collectionPath(article.Section)}, service.ArticleDirs(article)...), "index.md")...)
+}
+
+func (a *Astro) AssetsPath(article *service.Article) string {
+	return filepath.Join(a.assetsRoot(article.Section), filepath.FromSlash(service.ArticleSlug(article)), "assets")
+}
+
+func (a *Astro) StaticPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "public")
+}
+
+func (a *Astro) DataPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "src", "data")
+}
+
+func (a *Astro) Syntax() *render.Syntax {
+	return render.SyntaxCommonMark
+}
+
+func (a *Astro) FrontMatterFormat() string {
+	return FrontMatterYAML
+}
+
+// FrontMatter 使用 slug 固定文章在集合中的标识，页面路由需要与 sectionName 一致。
+// Astro 没有内置的草稿，预览时输出的 draft 需要在页面中自行过滤
+func (a *Astro) FrontMatter(article *service.Article, fmMap map[string]any) {
+	fmMap["slug"] = service.ArticleSlug(article)
+}
+
+func (a *Astro) Build() error {
+	return run("npm", "run", "build")
+}
+
+func (a *Astro) PublicPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "dist")
+}
@@ -0,0 +1,424 @@
+package exporter
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"hash/fnv"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syblog/config"
+	"syblog/logger"
+	"syblog/render"
+	"syblog/service"
+	"time"
+	"unicode"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+	luterender "github.com/88250/lute/render"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed layouts/*.html
+var layouts embed.FS
+
+// Builtin 内置的静态站点生成器，文章的输出布局与 Hugo 相同，构建时使用 lute 将 Markdown 渲染为 HTML，
+// 再使用 html/template 模板生成首页、文章页、标签页和归档页。
+// 模板可以通过博客目录下 layouts 中的同名文件覆盖：base.html、index.html、article.html、tags.html、tag.html、archive.html。
+type Builtin struct {
+	Hugo
+	sections []string
+}
+
+// builtinPage 描述了内置生成器中的一篇文章
+type builtinPage struct {
+	Title   string
+	URL     string
+	Date    time.Time
+	Lastmod time.Time
+	Tags    []string
+	Params  map[string]any
+	Content template.HTML
+
+	dir  string
+	path string
+}
+
+// builtinTag 描述了一个标签及其文章
+type builtinTag struct {
+	Name  string
+	Pages []*builtinPage
+}
+
+// builtinYear 描述了归档中的一年及其文章
+type builtinYear struct {
+	Year  int
+	Pages []*builtinPage
+}
+
+// builtinData 为模板的数据
+type builtinData struct {
+	Site  struct{ Title string }
+	Page  *builtinPage
+	Pages []*builtinPage
+	Tag   string
+	Tags  []*builtinTag
+	Years []*builtinYear
+}
+
+// builtinSyntax 为内置生成器的语法：lute 能够解析标题的 {#id}，并按 github 风格生成其余标题的锚点，不支持短代码
+var builtinSyntax = &render.Syntax{
+	Name:       "builtin",
+	Attrs:      render.AttrsHeading,
+	AutoAnchor: true,
+}
+
+func (b *Builtin) Name() string {
+	return "内置生成器"
+}
+
+func (b *Builtin) Syntax() *render.Syntax {
+	return builtinSyntax
+}
+
+// Prepare 内置生成器不需要为上级文档生成 section 页面
+func (b *Builtin) Prepare(articles *service.ArticleList) error {
+	b.findParents(articles)
+	b.sections = articles.Sections()
+	for _, s := range b.sections {
+		if err := markSection(b.sectionPath(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Builtin) Build() error {
+	publicPath := b.PublicPath()
+	if err := os.RemoveAll(publicPath); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := copyDir(b.StaticPath(), publicPath); err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return err
+	}
+
+	pages, err := b.loadPages()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Date.After(pages[j].Date)
+	})
+
+	data := builtinData{Pages: pages}
+	data.Site.Title = config.GetConfig().Hugo.Title
+	if data.Site.Title == "" {
+		data.Site.Title = config.GetConfig().Hugo.SectionName
+	}
+
+	// 地址相同的标签（如只有大小写不同）合并为一个标签页
+	tags := make(map[string]*builtinTag)
+	for _, p := range pages {
+		for _, t := range p.Tags {
+			slug := tagSlug(t)
+			if _, ok := tags[slug]; !ok {
+				tags[slug] = &builtinTag{Name: t}
+				data.Tags = append(data.Tags, tags[slug])
+			}
+			tags[slug].Pages = append(tags[slug].Pages, p)
+		}
+		if n := len(data.Years); n == 0 || data.Years[n-1].Year != p.Date.Year() {
+			data.Years = append(data.Years, &builtinYear{Year: p.Date.Year()})
+		}
+		data.Years[len(data.Years)-1].Pages = append(data.Years[len(data.Years)-1].Pages, p)
+	}
+	sort.Slice(data.Tags, func(i, j int) bool {
+		return data.Tags[i].Name < data.Tags[j].Name
+	})
+
+	for _, p := range pages {
+		d := data
+		d.Page = p
+		if err = b.execute("article", filepath.Join(publicPath, p.path, "index.html"), d); err != nil {
+			return err
+		}
+		if err = copyDir(filepath.Join(p.dir, "assets"), filepath.Join(publicPath, p.path, "assets")); err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return err
+		}
+	}
+	for _, t := range data.Tags {
+		d := data
+		d.Tag = t.Name
+		d.Pages = t.Pages
+		if err = b.execute("tag", filepath.Join(publicPath, "tags", tagSlug(t.Name), "index.html"), d); err != nil {
+			return err
+		}
+	}
+	if err = b.execute("tags", filepath.Join(publicPath, "tags", "index.html"), data); err != nil {
+		return err
+	}
+	if err = b.execute("archive", filepath.Join(publicPath, "archive", "index.html"), data); err != nil {
+		return err
+	}
+	return b.execute("index", filepath.Join(publicPath, "index.html"), data)
+}
+
+// loadPages 读取已输出的文章并渲染为 HTML，draft 为 true 的文章只在预览时生成
+func (b *Builtin) loadPages() ([]*builtinPage, error) {
+	var pages []*builtinPage
+	for _, section := range b.sections {
+		err := filepath.WalkDir(b.sectionPath(section), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (d.Name() != "index.md" && d.Name() != "_index.md") {
+				return nil
+			}
+			rel, err := filepath.Rel(config.GetConfig().Hugo.BlogPath, filepath.Dir(p))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			bs, err := os.ReadFile(p)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			fmMap, body, err := UnmarshalFrontMatter(bs)
+			if err != nil {
+				return errors.Wrapf(err, "Front Matter解析失败：%s", p)
+			}
+			if draft, _ := fmMap["draft"].(bool); draft && !config.GetConfig().Hugo.Preview {
+				return nil
+			}
+			page := &builtinPage{Params: fmMap, dir: filepath.Dir(p)}
+			page.Title, _ = fmMap["title"].(string)
+			page.path = strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(filepath.ToSlash(rel), "content/"), " ", "-"))
+			dirs := strings.Split(page.path, "/")
+			for i, d := range dirs {
+				dirs[i] = url.QueryEscape(d)
+			}
+			page.URL = "/" + strings.Join(dirs, "/") + "/"
+			page.path = filepath.FromSlash(page.path)
+			page.Date = frontMatterTime(fmMap["date"])
+			page.Lastmod = frontMatterTime(fmMap["lastmod"])
+			if tags, ok := fmMap["tags"].([]any); ok {
+				for _, t := range tags {
+					if s, ok := t.(string); ok {
+						page.Tags = append(page.Tags, s)
+					}
+				}
+			}
+			toc, _ := fmMap["toc"].(bool)
+			page.Content = template.HTML(markdownToHTML(body, toc))
+			pages = append(pages, page)
+			return nil
+		})
+		if err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// execute 使用指定的模板生成页面，优先使用博客目录 layouts 中的模板
+func (b *Builtin) execute(name, dst string, data builtinData) error {
+	tpl := template.New("base").Funcs(template.FuncMap{
+		"now":    time.Now,
+		"tagURL": tagURL,
+	})
+	for _, n := range []string{"base", name} {
+		bs, err := os.ReadFile(filepath.Join(config.GetConfig().Hugo.BlogPath, "layouts", n+".html"))
+		if err != nil {
+			bs, err = layouts.ReadFile("layouts/" + n + ".html")
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if _, err = tpl.Parse(string(bs)); err != nil {
+			return errors.Wrapf(err, "模板解析失败：%s", n)
+		}
+	}
+	buf := bytes.Buffer{}
+	if err := tpl.ExecuteTemplate(&buf, "base", data); err != nil {
+		return errors.Wrapf(err, "模板执行失败：%s", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(dst, buf.Bytes(), 0644))
+}
+
+// markdownToHTML 使用 lute 将文章渲染为 HTML。
+// Goldmark 的块属性会被去掉，文章中的短代码除 toc 外均不支持，toc 为 true 时在文章开头生成目录。
+func markdownToHTML(md string, toc bool) string {
+	md = render.StripAttrs(md)
+	md = render.ReplaceShortcodes(md, func(name, arg string) string {
+		if name == "toc" {
+			return "[toc]"
+		}
+		logger.Warnf("内置生成器不支持短代码：%s", name)
+		return ""
+	})
+	if toc {
+		md = "[toc]\n\n" + md
+	}
+
+	luteEngine := lute.New()
+	luteEngine.ParseOptions.Mark = true
+	luteEngine.ParseOptions.Sup = true
+	luteEngine.ParseOptions.Sub = true
+	luteEngine.ParseOptions.ToC = true
+	luteEngine.ParseOptions.HeadingID = true
+	luteEngine.RenderOptions.ToC = true
+	tree := parse.Parse("", []byte(md), luteEngine.ParseOptions)
+	render.AnchorHeadings(tree)
+	renderer := luterender.NewHtmlRenderer(tree, luteEngine.RenderOptions)
+	nav := tocHTML(tree)
+	renderer.ExtRendererFuncs[ast.NodeToC] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if entering {
+			return nav, ast.WalkContinue
+		}
+		return "", ast.WalkContinue
+	}
+	return string(renderer.Render())
+}
+
+// tocHTML 生成由标题链接组成的嵌套目录
+func tocHTML(tree *parse.Tree) string {
+	buf := bytes.Buffer{}
+	var levels []int
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeHeading != n.Type {
+			return ast.WalkContinue
+		}
+		for len(levels) > 0 && levels[len(levels)-1] > n.HeadingLevel {
+			buf.WriteString("</li></ul>")
+			levels = levels[:len(levels)-1]
+		}
+		if len(levels) == 0 || levels[len(levels)-1] < n.HeadingLevel {
+			buf.WriteString("<ul><li>")
+			levels = append(levels, n.HeadingLevel)
+		} else {
+			buf.WriteString("</li><li>")
+		}
+		buf.WriteString("<a href=\"#" + html.EscapeAttrVal(n.HeadingNormalizedID) + "\">" + html.EscapeString(strings.TrimSpace(render.PlainText(n))) + "</a>")
+		return ast.WalkSkipChildren
+	})
+	buf.WriteString(strings.Repeat("</li></ul>", len(levels)))
+	return "<nav class=\"toc\">" + buf.String() + "</nav>\n"
+}
+
+// UnmarshalFrontMatter 解析 Markdown 开头的 TOML、YAML 或 JSON 格式的 Front Matter，返回 Front Matter 和正文
+func UnmarshalFrontMatter(bs []byte) (map[string]any, string, error) {
+	fmMap := make(map[string]any)
+	content := string(bs)
+	for _, f := range []struct {
+		fence     string
+		unmarshal func([]byte, any) error
+	}{{"+++", toml.Unmarshal}, {"---", yaml.Unmarshal}} {
+		if !strings.HasPrefix(content, f.fence) {
+			continue
+		}
+		rest := strings.TrimLeft(content[len(f.fence):], "\r\n")
+		end := strings.Index(rest, "\n"+f.fence)
+		if end < 0 {
+			return nil, "", errors.Errorf("缺少Front Matter结束标记%s", f.fence)
+		}
+		if err := f.unmarshal([]byte(rest[:end]), &fmMap); err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		return fmMap, strings.TrimLeft(rest[end+1+len(f.fence):], "\r\n"), nil
+	}
+	if strings.HasPrefix(content, "{") {
+		dec := json.NewDecoder(strings.NewReader(content))
+		if err := dec.Decode(&fmMap); err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		return fmMap, strings.TrimLeft(content[dec.InputOffset():], "\r\n"), nil
+	}
+	return fmMap, content, nil
+}
+
+// frontMatterTime 将 Front Matter 中的日期转换为时间，支持 TOML 本地日期时间、YAML 时间戳和 RFC3339 字符串
+func frontMatterTime(v any) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case toml.LocalDateTime:
+		return t.AsTime(time.Local)
+	case toml.LocalDate:
+		return t.AsTime(time.Local)
+	case string:
+		ret, _ := time.Parse(time.RFC3339, t)
+		return ret
+	}
+	return time.Time{}
+}
+
+// tagURL 返回标签页的访问地址
+func tagURL(tag string) string {
+	return "/tags/" + url.PathEscape(tagSlug(tag)) + "/"
+}
+
+// tagSlug 返回标签页的目录名：转为小写，字母、数字、- 和 _ 以外的字符（包括 / 和 .）替换为 -，
+// 没有可用字符时使用标签的哈希值，保证目录位于 tags 之下
+func tagSlug(tag string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(strings.TrimSpace(tag)) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' {
+			b.WriteRune(c)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		h := fnv.New32a()
+		h.Write([]byte(tag))
+		slug = "tag-" + strconv.FormatUint(uint64(h.Sum32()), 16)
+	}
+	return slug
+}
+
+// copyDir 将 src 目录中的文件复制到 dst 目录
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return errors.WithStack(os.MkdirAll(target, 0755))
+		}
+		in, err := os.Open(p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer out.Close()
+		_, err = io.Copy(out, in)
+		return errors.WithStack(err)
+	})
+}
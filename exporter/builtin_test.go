@@ -0,0 +1,32 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagSlug(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"Go", "go"},
+		{"GO", "go"},
+		{"思源 笔记", "思源-笔记"},
+		{"a/b", "a-b"},
+		{"../../etc", "etc"},
+		{"C++", "c"},
+		{"snake_case", "snake_case"},
+	}
+	for _, tt := range tests {
+		if got := tagSlug(tt.tag); got != tt.want {
+			t.Errorf("tagSlug(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+	// 没有可用字符的标签使用哈希值，不同的标签不会共用目录
+	for _, tag := range []string{"..", "/", "?"} {
+		if got := tagSlug(tag); !strings.HasPrefix(got, "tag-") || got == tagSlug("!") {
+			t.Errorf("tagSlug(%q) = %q", tag, got)
+		}
+	}
+}
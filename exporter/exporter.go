@@ -0,0 +1,285 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syblog/config"
+	"syblog/logger"
+	"syblog/render"
+	"syblog/service"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Front Matter 的格式
+const (
+	FrontMatterTOML = "toml"
+	FrontMatterYAML = "yaml"
+	FrontMatterJSON = "json"
+)
+
+// Exporter 描述了一种静态站点生成器的内容布局：文章和资源的存放位置、Front Matter 的格式和字段以及站点的构建方式。
+// 文章中的资源均以 assets/ 相对路径引用，文章的访问地址均为 service.ArticleLink 返回的地址。
+type Exporter interface {
+	// Name 返回生成器的名称
+	Name() string
+	// CleanPaths 返回每次发布前需要清理的目录
+	CleanPaths(articles *service.ArticleList) []string
+	// Prepare 在输出文章前调用，用于生成必需的目录或文件，articles 为所有需要发布的文章
+	Prepare(articles *service.ArticleList) error
+	// ArticlePath 返回文章 Markdown 文件的路径
+	ArticlePath(article *service.Article) string
+	// AssetsPath 返回文章资源文件夹的路径
+	AssetsPath(article *service.Article) string
+	// StaticPath 返回站点静态文件目录，其中的文件按原路径发布
+	StaticPath() string
+	// DataPath 返回站点数据文件目录，其中的 JSON 文件可以在模板中读取
+	DataPath() string
+	// FrontMatterFormat 返回 Front Matter 的格式
+	FrontMatterFormat() string
+	// Syntax 返回生成器支持的短代码和属性语法，渲染文章时不支持的语法会回退为 HTML
+	Syntax() *render.Syntax
+	// FrontMatter 将通用的 Front Matter 调整为生成器约定的字段
+	FrontMatter(article *service.Article, fmMap map[string]any)
+	// Build 执行生成器构建站点
+	Build() error
+	// PublicPath 返回构建后站点的输出目录
+	PublicPath() string
+}
+
+// GetExporter 根据名称获取生成器，名称为空或未知时返回 Hugo
+func GetExporter(name string) Exporter {
+	switch strings.ToLower(name) {
+	case "hexo":
+		return &Hexo{}
+	case "jekyll":
+		return &Jekyll{}
+	case "zola":
+		return &Zola{}
+	case "astro":
+		return &Astro{}
+	case "builtin":
+		return &Builtin{}
+	default:
+		return &Hugo{}
+	}
+}
+
+// MarshalFrontMatter 按照指定的格式生成带分隔符的 Front Matter，时间均输出为对应格式的日期类型
+func MarshalFrontMatter(format string, fmMap map[string]any) ([]byte, error) {
+	buf := bytes.Buffer{}
+	switch format {
+	case FrontMatterYAML:
+		bs, err := yaml.Marshal(fmMap)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\r\n")
+		buf.Write(bs)
+		buf.WriteString("---\r\n\r\n")
+	case FrontMatterJSON:
+		bs, err := json.MarshalIndent(fmMap, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(bs)
+		buf.WriteString("\r\n\r\n")
+	default:
+		bs, err := toml.Marshal(tomlValue(fmMap))
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("+++\r\n")
+		buf.Write(bs)
+		buf.WriteString("+++\r\n\r\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// offsetDateKeys 为需要保留时区的时间。Hugo 按站点的 timeZone（默认为 UTC）解释本地日期时间，
+// 定时发布和过期的时间会因此偏移
+var offsetDateKeys = map[string]bool{"publishDate": true, "expiryDate": true}
+
+// tomlValue 将值中的时间转换为 TOML 的本地日期时间，offsetDateKeys 中的时间输出为带时区的日期时间
+func tomlValue(v any) any {
+	switch val := v.(type) {
+	case time.Time:
+		return tomlLocalDateTime(val)
+	case map[string]any:
+		ret := make(map[string]any, len(val))
+		for k, item := range val {
+			if t, ok := item.(time.Time); ok && offsetDateKeys[k] {
+				ret[k] = t
+				continue
+			}
+			ret[k] = tomlValue(item)
+		}
+		return ret
+	default:
+		return v
+	}
+}
+
+func tomlLocalDateTime(t time.Time) toml.LocalDateTime {
+	return toml.LocalDateTime{
+		LocalDate: toml.LocalDate{
+			Year:  t.Year(),
+			Month: int(t.Month()),
+			Day:   t.Day(),
+		},
+		LocalTime: toml.LocalTime{
+			Hour:   t.Hour(),
+			Minute: t.Minute(),
+			Second: t.Second(),
+		},
+	}
+}
+
+// run 在博客目录下执行生成器命令，配置了 excutePath 时使用配置的可执行程序代替 name
+func run(name string, args ...string) error {
+	if p := config.GetConfig().Hugo.ExcutePath; p != "" {
+		name = p
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Dir = config.GetConfig().Hugo.BlogPath
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// frontMatterFormat 返回配置的 Front Matter 格式，未配置或不在 allowed 中时返回 allowed 的第一个格式
+func frontMatterFormat(allowed ...string) string {
+	format := strings.ToLower(config.GetConfig().Hugo.FrontMatter)
+	for _, f := range allowed {
+		if f == format {
+			return f
+		}
+	}
+	return allowed[0]
+}
+
+// flatName 返回文章在 section 中不会重名的文件名：按文档树输出时为各级目录名以 - 连接，否则为文档标题。
+// 用于 Hexo 的文章资源文件夹和 Zola 的页面包等不能嵌套文章的位置
+func flatName(article *service.Article) string {
+	return strings.Join(service.ArticleDirs(article), "-")
+}
+
+// ownerFile 为在站点根目录等共享位置生成的目录中放置的标记文件，用于区分站点中已有的同名目录
+const ownerFile = ".syblog"
+
+// ownedPath 返回可以清理的目录：目录不存在或带有标记文件时返回 dir，
+// 否则说明 section 与站点中已有的目录重名（如 assets、_layouts），终止发布以免删除用户的文件
+func ownedPath(dir string) string {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return dir
+	}
+	if _, err := os.Stat(filepath.Join(dir, ownerFile)); err == nil {
+		return dir
+	}
+	logger.Fatalf("%+v", errors.Errorf("目录%s已存在且不是由syblog生成，请修改section名称", dir))
+	return ""
+}
+
+// markOwned 创建目录并放置标记文件
+func markOwned(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ownerFile), nil, 0644)
+}
+
+// manifestFile 为站点中已有的 section 目录中记录 syblog 写入内容的清单，每行一个相对路径
+const manifestFile = ".syblog-files"
+
+// sharedSection 描述了站点中已有的 section 目录：发布前已存在的条目属于用户，syblog 写入的内容记录在清单中
+type sharedSection struct {
+	existing map[string]bool
+	written  []string
+	recorded map[string]bool
+}
+
+// sharedSections 为本次发布中站点已有的 section 目录
+var sharedSections = make(map[string]*sharedSection)
+
+// sectionCleanPaths 返回发布前清理 section 目录需要删除的路径：目录由 syblog 创建（带有标记文件）时为整个目录，
+// 站点中已有的目录（如手写文章所在的 content/posts）只删除清单中记录的上次发布写入的内容
+func sectionCleanPaths(dir string) []string {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, ownerFile)); err == nil {
+		return []string{dir}
+	}
+	bs, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil
+	}
+	var ret []string
+	for _, line := range strings.Split(string(bs), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		// 清单被修改时也不删除 section 目录之外的文件
+		if rel := filepath.Clean(filepath.FromSlash(line)); rel != "." && !filepath.IsAbs(rel) && !strings.HasPrefix(rel, "..") {
+			ret = append(ret, filepath.Join(dir, rel))
+		}
+	}
+	return append(ret, filepath.Join(dir, manifestFile))
+}
+
+// markSection 在写入文章前标记 section 目录：目录不存在时由 syblog 创建并放置标记文件，
+// 已存在且没有标记文件时记录其中已有的条目，之后写入的内容由 Written 记录到清单
+func markSection(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return markOwned(dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ownerFile)); err == nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	s := &sharedSection{existing: make(map[string]bool), recorded: make(map[string]bool)}
+	for _, e := range entries {
+		s.existing[e.Name()] = true
+	}
+	sharedSections[dir] = s
+	return nil
+}
+
+// Written 记录发布时写入的文件或目录。位于站点已有的 section 目录中时，syblog 新建的顶层条目整体记入清单，
+// 与用户已有的条目同名时只记录写入的路径
+func Written(p string) {
+	for dir, s := range sharedSections {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if top := strings.Split(rel, "/")[0]; !s.existing[top] {
+			rel = top
+		}
+		if !s.recorded[rel] {
+			s.recorded[rel] = true
+			s.written = append(s.written, rel)
+		}
+	}
+}
+
+// SaveManifests 保存站点已有的 section 目录的清单，下次发布时只清理清单中的内容
+func SaveManifests() error {
+	for dir, s := range sharedSections {
+		if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(strings.Join(s.written, "\n")+"\n"), 0644); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+func TestFrontMatterRoundTrip(t *testing.T) {
+	date := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	fm := map[string]any{
+		"title": "Hello 世界",
+		"date":  date,
+		"tags":  []string{"Go", "笔记"},
+		"draft": true,
+		"cover": map[string]any{"image": "/a.png"},
+	}
+	tests := []struct {
+		format string
+		prefix string
+		date   any
+	}{
+		// TOML 中的时间输出为本地日期时间
+		{FrontMatterTOML, "+++\r\n", toml.LocalDateTime{
+			LocalDate: toml.LocalDate{Year: 2022, Month: 3, Day: 4},
+			LocalTime: toml.LocalTime{Hour: 5, Minute: 6, Second: 7},
+		}},
+		{FrontMatterYAML, "---\r\n", date},
+		{FrontMatterJSON, "{", "2022-03-04T05:06:07Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			bs, err := MarshalFrontMatter(tt.format, fm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(bs[:len(tt.prefix)]); got != tt.prefix {
+				t.Errorf("MarshalFrontMatter() prefix = %q, want %q", got, tt.prefix)
+			}
+			got, content, err := UnmarshalFrontMatter(append(bs, "正文\n"...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]any{
+				"title": "Hello 世界",
+				"date":  tt.date,
+				"tags":  []any{"Go", "笔记"},
+				"draft": true,
+				"cover": map[string]any{"image": "/a.png"},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("UnmarshalFrontMatter() = %#v, want %#v", got, want)
+			}
+			if content != "正文\n" {
+				t.Errorf("UnmarshalFrontMatter() content = %q, want %q", content, "正文\n")
+			}
+		})
+	}
+}
+
+func TestUnmarshalFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		md      string
+		want    map[string]any
+		content string
+		wantErr bool
+	}{
+		{"没有Front Matter", "# 标题\n", map[string]any{}, "# 标题\n", false},
+		{"LF换行", "+++\ntitle = 'a'\n+++\n\n正文", map[string]any{"title": "a"}, "正文", false},
+		{"正文中的分隔符", "---\ntitle: a\n---\n正文\n\n---\n", map[string]any{"title": "a"}, "正文\n\n---\n", false},
+		{"缺少结束标记", "+++\ntitle = 'a'\n", nil, "", true},
+		{"格式错误", "---\ntitle: [a\n---\n", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, content, err := UnmarshalFrontMatter([]byte(tt.md))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalFrontMatter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalFrontMatter() = %#v, want %#v", got, tt.want)
+			}
+			if content != tt.content {
+				t.Errorf("UnmarshalFrontMatter() content = %q, want %q", content, tt.content)
+			}
+		})
+	}
+}
+
+func TestSectionCleanPaths(t *testing.T) {
+	root := t.TempDir()
+	owned := filepath.Join(root, "notes")
+	shared := filepath.Join(root, "posts")
+	if err := os.MkdirAll(filepath.Join(shared, "手写"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shared, "_index.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次发布：不清理任何内容，syblog 创建的目录带有标记文件
+	for _, dir := range []string{owned, shared} {
+		if got := sectionCleanPaths(dir); len(got) != 0 {
+			t.Errorf("sectionCleanPaths(%s) = %v, want none", dir, got)
+		}
+		if err := markSection(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Written(filepath.Join(owned, "文章", "index.md"))
+	Written(filepath.Join(shared, "文章", "index.md"))
+	Written(filepath.Join(shared, "文章", "assets"))
+	Written(filepath.Join(shared, "手写", "assets"))
+	if err := SaveManifests(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二次发布：syblog 创建的目录整体清理，已有的目录只清理清单中的内容
+	if got, want := sectionCleanPaths(owned), []string{owned}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sectionCleanPaths(owned) = %v, want %v", got, want)
+	}
+	want := []string{
+		filepath.Join(shared, "文章"),
+		filepath.Join(shared, "手写", "assets"),
+		filepath.Join(shared, manifestFile),
+	}
+	if got := sectionCleanPaths(shared); !reflect.DeepEqual(got, want) {
+		t.Errorf("sectionCleanPaths(shared) = %v, want %v", got, want)
+	}
+
+	// 被修改的清单不能指向 section 目录之外
+	if err := os.WriteFile(filepath.Join(shared, manifestFile), []byte("../notes\n.\n/etc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sectionCleanPaths(shared), []string{filepath.Join(shared, manifestFile)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sectionCleanPaths(tampered) = %v, want %v", got, want)
+	}
+}
+
+func TestMarshalFrontMatterOffsetDates(t *testing.T) {
+	zone := time.FixedZone("UTC+8", 8*60*60)
+	fm := map[string]any{
+		"date":        time.Date(2022, 8, 1, 8, 0, 0, 0, zone),
+		"publishDate": time.Date(2022, 8, 1, 8, 0, 0, 0, zone),
+		"expiryDate":  time.Date(2022, 9, 1, 0, 0, 0, 0, zone),
+	}
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FrontMatterTOML, []string{"date = 2022-08-01T08:00:00\r\n", "publishDate = 2022-08-01T08:00:00+08:00", "expiryDate = 2022-09-01T00:00:00+08:00"}},
+		{FrontMatterYAML, []string{"publishDate: 2022-08-01T08:00:00+08:00", "expiryDate: 2022-09-01T00:00:00+08:00"}},
+		{FrontMatterJSON, []string{`"publishDate": "2022-08-01T08:00:00+08:00"`, `"expiryDate": "2022-09-01T00:00:00+08:00"`}},
+	}
+	for _, tt := range tests {
+		bs, err := MarshalFrontMatter(tt.format, fm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(strings.ReplaceAll(string(bs), "\r\n", "\n"), strings.ReplaceAll(w, "\r\n", "\n")) {
+				t.Errorf("MarshalFrontMatter(%s) = %q, want %q", tt.format, bs, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"path/filepath"
+	"strings"
+	"syblog/config"
+	"syblog/render"
+	"syblog/service"
+)
+
+// Hexo 将文章输出为 source/_posts/<section>/<标题>.md，按文档树输出时文件名为各级文档标题以 - 连接，
+// 资源放在同名的文章资源文件夹中，需要开启 post_asset_folder
+type Hexo struct{}
+
+func (h *Hexo) Name() string {
+	return "Hexo"
+}
+
+func (h *Hexo) sectionPath(section string) string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "source", "_posts", section)
+}
+
+func (h *Hexo) CleanPaths(articles *service.ArticleList) []string {
+	var ret []string
+	for _, s := range articles.Sections() {
+		ret = append(ret, sectionCleanPaths(h.sectionPath(s))...)
+	}
+	return ret
+}
+
+func (h *Hexo) Prepare(articles *service.ArticleList) error {
+	for _, s := range articles.Sections() {
+		if err := markSection(h.sectionPath(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Hexo) ArticlePath(article *service.Article) string {
+	return filepath.Join(h.sectionPath(article.Section), flatName(article)+".md")
+}
+
+func (h *Hexo) AssetsPath(article *service.Article) string {
+	return filepath.Join(h.sectionPath(article.Section), flatName(article), "assets")
+}
+
+func (h *Hexo) StaticPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "source")
+}
+
+func (h *Hexo) DataPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "source", "_data")
+}
+
+func (h *Hexo) Syntax() *render.Syntax {
+	return render.SyntaxCommonMark
+}
+
+func (h *Hexo) FrontMatterFormat() string {
+	return FrontMatterYAML
+}
+
+// FrontMatter 使用 permalink 固定文章地址，lastmod 改为 Hexo 的 updated，草稿改为 published: false，
+// 关闭 Nunjucks 渲染，避免文章中的 {{ 和 {% 被当作模板语法
+func (h *Hexo) FrontMatter(article *service.Article, fmMap map[string]any) {
+	fmMap["permalink"] = strings.TrimPrefix(service.ArticleLink(article), "/")
+	renameKey(fmMap, "lastmod", "updated")
+	if draft, _ := fmMap["draft"].(bool); draft {
+		delete(fmMap, "draft")
+		fmMap["published"] = false
+	}
+	fmMap["disableNunjucks"] = true
+}
+
+// Build 预览时同时生成 published: false 的草稿
+func (h *Hexo) Build() error {
+	if config.GetConfig().Hugo.Preview {
+		return run("hexo", "generate", "--draft")
+	}
+	return run("hexo", "generate")
+}
+
+func (h *Hexo) PublicPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "public")
+}
+
+// renameKey 将 Front Matter 中的键 from 重命名为 to
+func renameKey(fmMap map[string]any, from, to string) {
+	if v, ok := fmMap[from]; ok {
+		delete(fmMap, from)
+		fmMap[to] = v
+	}
+}
@@ -0,0 +1,148 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"syblog/config"
+	"syblog/render"
+	"syblog/service"
+)
+
+// Hugo 将文章输出为 content/<section>/<标题>/index.md 页面包，资源与文章放在一起。
+// 按文档树输出时，文章放在各级上级文档标题组成的目录中，有子文档的文章输出为 _index.md，成为嵌套的 section。
+type Hugo struct {
+	parents map[string]bool
+}
+
+func (h *Hugo) Name() string {
+	return "Hugo"
+}
+
+func (h *Hugo) sectionPath(section string) string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "content", section)
+}
+
+func (h *Hugo) CleanPaths(articles *service.ArticleList) []string {
+	var ret []string
+	for _, s := range articles.Sections() {
+		ret = append(ret, sectionCleanPaths(h.sectionPath(s))...)
+	}
+	return ret
+}
+
+// Prepare 标记各个 section 目录，按文档树输出时为没有发布的上级文档生成只有标题和排序的 _index.md
+func (h *Hugo) Prepare(articles *service.ArticleList) error {
+	h.findParents(articles)
+	for _, s := range articles.Sections() {
+		if err := markSection(h.sectionPath(s)); err != nil {
+			return err
+		}
+	}
+	if !config.GetConfig().Hugo.Hierarchy {
+		return nil
+	}
+	written := make(map[string]bool)
+	for e := articles.Front(); e != nil; e = e.Next() {
+		article := e.Value.(*service.Article)
+		dirs := service.ArticleDirs(article)
+		for i, p := range article.Ancestors() {
+			if i >= len(dirs)-1 || articles.Exist(service.PathID(p)) {
+				continue
+			}
+			dir := filepath.Join(append([]string{h.sectionPath(article.Section)}, dirs[:i+1]...)...)
+			if written[dir] {
+				continue
+			}
+			written[dir] = true
+			// 站点中已有的 _index.md 由用户维护
+			indexPath := filepath.Join(dir, "_index.md")
+			if _, err := os.Stat(indexPath); err == nil {
+				continue
+			}
+			fmMap := map[string]any{"title": dirs[i]}
+			if weight := service.FindDocWeight(article.Box, p); weight > 0 {
+				fmMap["weight"] = weight
+			}
+			fm, err := MarshalFrontMatter(h.FrontMatterFormat(), fmMap)
+			if err != nil {
+				return err
+			}
+			if err = os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			if err = os.WriteFile(indexPath, fm, 0644); err != nil {
+				return err
+			}
+			Written(indexPath)
+		}
+	}
+	return nil
+}
+
+// findParents 记录有子文档需要发布的文档
+func (h *Hugo) findParents(articles *service.ArticleList) {
+	h.parents = make(map[string]bool)
+	for e := articles.Front(); e != nil; e = e.Next() {
+		for _, p := range e.Value.(*service.Article).Ancestors() {
+			h.parents[service.PathID(p)] = true
+		}
+	}
+}
+
+func (h *Hugo) articleDir(article *service.Article) string {
+	return filepath.Join(append([]string{h.sectionPath(article.Section)}, service.ArticleDirs(article)...)...)
+}
+
+func (h *Hugo) ArticlePath(article *service.Article) string {
+	if config.GetConfig().Hugo.Hierarchy && h.parents[article.ID] {
+		return filepath.Join(h.articleDir(article), "_index.md")
+	}
+	return filepath.Join(h.articleDir(article), "index.md")
+}
+
+func (h *Hugo) AssetsPath(article *service.Article) string {
+	return filepath.Join(h.articleDir(article), "assets")
+}
+
+func (h *Hugo) StaticPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "static")
+}
+
+func (h *Hugo) DataPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "data")
+}
+
+func (h *Hugo) Syntax() *render.Syntax {
+	if config.GetConfig().Hugo.BlockAttributes {
+		return render.SyntaxHugoBlock
+	}
+	return render.SyntaxHugo
+}
+
+func (h *Hugo) FrontMatterFormat() string {
+	return frontMatterFormat(FrontMatterTOML, FrontMatterYAML, FrontMatterJSON)
+}
+
+// FrontMatter 按文档树输出时，没有设置 weight 的文章使用文档树中的排序
+func (h *Hugo) FrontMatter(article *service.Article, fmMap map[string]any) {
+	if !config.GetConfig().Hugo.Hierarchy {
+		return
+	}
+	if _, ok := fmMap["weight"]; !ok {
+		if weight := service.FindDocWeight(article.Box, article.Path); weight > 0 {
+			fmMap["weight"] = weight
+		}
+	}
+}
+
+// Build 预览时同时生成草稿
+func (h *Hugo) Build() error {
+	if config.GetConfig().Hugo.Preview {
+		return run("hugo", "--buildDrafts")
+	}
+	return run("hugo")
+}
+
+func (h *Hugo) PublicPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "public")
+}
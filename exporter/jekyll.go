@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"path/filepath"
+	"syblog/config"
+	"syblog/render"
+	"syblog/service"
+)
+
+// Jekyll 将文章输出为 _posts/<section>/<日期>-<标题>.md，按文档树输出时放在各级上级文档标题组成的目录中，
+// 资源放在与文章地址相同的站点目录中
+type Jekyll struct{}
+
+func (j *Jekyll) Name() string {
+	return "Jekyll"
+}
+
+func (j *Jekyll) postsPath(section string) string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "_posts", section)
+}
+
+// assetsRoot 返回资源的根目录，Jekyll 会原样复制不以下划线开头的目录
+func (j *Jekyll) assetsRoot(section string) string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, section)
+}
+
+func (j *Jekyll) CleanPaths(articles *service.ArticleList) []string {
+	var ret []string
+	for _, s := range articles.Sections() {
+		ret = append(append(ret, sectionCleanPaths(j.postsPath(s))...), ownedPath(j.assetsRoot(s)))
+	}
+	return ret
+}
+
+// Prepare 创建各个 section 的资源根目录并放置标记文件，之后的发布只会清理带有标记文件的目录
+func (j *Jekyll) Prepare(articles *service.ArticleList) error {
+	for _, s := range articles.Sections() {
+		if err := markSection(j.postsPath(s)); err != nil {
+			return err
+		}
+		if err := markOwned(j.assetsRoot(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *Jekyll) ArticlePath(article *service.Article) string {
+	dirs := service.ArticleDirs(article)
+	dirs[len(dirs)-1] = article.Created.Format("2006-01-02") + "-" + dirs[len(dirs)-1] + ".md"
+	return filepath.Join(append([]string{j.postsPath(article.Section)}, dirs...)...)
+}
+
+func (j *Jekyll) AssetsPath(article *service.Article) string {
+	return filepath.Join(j.assetsRoot(article.Section), filepath.FromSlash(service.ArticleSlug(article)), "assets")
+}
+
+func (j *Jekyll) StaticPath() string {
+	return config.GetConfig().Hugo.BlogPath
+}
+
+func (j *Jekyll) DataPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "_data")
+}
+
+func (j *Jekyll) Syntax() *render.Syntax {
+	return render.SyntaxKramdown
+}
+
+func (j *Jekyll) FrontMatterFormat() string {
+	return FrontMatterYAML
+}
+
+// FrontMatter 使用 permalink 固定文章地址，lastmod 改为 jekyll-seo-tag 等插件使用的 last_modified_at，
+// Jekyll 不识别 draft，草稿改为 published: false。关闭 Liquid 渲染，避免文章中的 {{ 和 {% 被当作模板语法
+func (j *Jekyll) FrontMatter(article *service.Article, fmMap map[string]any) {
+	fmMap["permalink"] = service.ArticleLink(article)
+	renameKey(fmMap, "lastmod", "last_modified_at")
+	fmMap["render_with_liquid"] = false
+	if draft, _ := fmMap["draft"].(bool); draft {
+		delete(fmMap, "draft")
+		fmMap["published"] = false
+	}
+}
+
+// Build 预览时同时生成 published: false 的草稿
+func (j *Jekyll) Build() error {
+	if config.GetConfig().Hugo.Preview {
+		return run("jekyll", "build", "--unpublished")
+	}
+	return run("jekyll", "build")
+}
+
+func (j *Jekyll) PublicPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "_site")
+}
@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syblog/config"
+	"syblog/render"
+	"syblog/service"
+)
+
+// zolaKeys 为 Zola 页面 Front Matter 中允许的顶层键，其余键需要放到 extra 中
+var zolaKeys = map[string]bool{
+	"title": true, "description": true, "date": true, "updated": true, "weight": true, "draft": true,
+	"slug": true, "path": true, "aliases": true, "authors": true, "in_search_index": true,
+	"template": true, "taxonomies": true, "extra": true,
+}
+
+// Zola 将文章输出为 content/<section>/<标题>/index.md 页面包，按文档树输出时目录名为各级文档标题以 - 连接，资源与文章放在一起
+type Zola struct{}
+
+func (z *Zola) Name() string {
+	return "Zola"
+}
+
+func (z *Zola) sectionPath(section string) string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "content", section)
+}
+
+func (z *Zola) CleanPaths(articles *service.ArticleList) []string {
+	var ret []string
+	for _, s := range articles.Sections() {
+		ret = append(ret, sectionCleanPaths(z.sectionPath(s))...)
+	}
+	return ret
+}
+
+// Prepare 生成各个 section 的 _index.md，Zola 只渲染属于 section 的页面
+func (z *Zola) Prepare(articles *service.ArticleList) error {
+	for _, s := range articles.Sections() {
+		if err := markSection(z.sectionPath(s)); err != nil {
+			return err
+		}
+		// 站点中已有的 _index.md 由用户维护
+		p := filepath.Join(z.sectionPath(s), "_index.md")
+		if _, err := os.Stat(p); err == nil {
+			continue
+		}
+		fm, err := MarshalFrontMatter(z.FrontMatterFormat(), map[string]any{
+			"title":   s,
+			"sort_by": "date",
+		})
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(p, fm, 0644); err != nil {
+			return err
+		}
+		Written(p)
+	}
+	return nil
+}
+
+func (z *Zola) ArticlePath(article *service.Article) string {
+	return filepath.Join(z.sectionPath(article.Section), flatName(article), "index.md")
+}
+
+func (z *Zola) AssetsPath(article *service.Article) string {
+	return filepath.Join(z.sectionPath(article.Section), flatName(article), "assets")
+}
+
+func (z *Zola) StaticPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "static")
+}
+
+// DataPath 返回 data 目录，在模板中通过 load_data 读取
+func (z *Zola) DataPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "data")
+}
+
+func (z *Zola) Syntax() *render.Syntax {
+	return render.SyntaxZola
+}
+
+func (z *Zola) FrontMatterFormat() string {
+	return frontMatterFormat(FrontMatterTOML, FrontMatterYAML)
+}
+
+// FrontMatter 使用 path 固定文章地址，tags 放到 taxonomies 中，Zola 不认识的键放到 extra 中
+func (z *Zola) FrontMatter(article *service.Article, fmMap map[string]any) {
+	fmMap["path"] = strings.Trim(service.ArticleLink(article), "/")
+	renameKey(fmMap, "lastmod", "updated")
+	if tags, ok := fmMap["tags"]; ok {
+		delete(fmMap, "tags")
+		taxonomies, ok := fmMap["taxonomies"].(map[string]any)
+		if !ok {
+			taxonomies = make(map[string]any)
+			fmMap["taxonomies"] = taxonomies
+		}
+		taxonomies["tags"] = tags
+	}
+	extra, ok := fmMap["extra"].(map[string]any)
+	if !ok {
+		extra = make(map[string]any)
+	}
+	for k, v := range fmMap {
+		if !zolaKeys[k] {
+			extra[k] = v
+			delete(fmMap, k)
+		}
+	}
+	if len(extra) > 0 {
+		fmMap["extra"] = extra
+	}
+}
+
+// Build 预览时同时生成草稿
+func (z *Zola) Build() error {
+	if config.GetConfig().Hugo.Preview {
+		return run("zola", "build", "--drafts")
+	}
+	return run("zola", "build")
+}
+
+func (z *Zola) PublicPath() string {
+	return filepath.Join(config.GetConfig().Hugo.BlogPath, "public")
+}
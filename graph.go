@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syblog/config"
+	"syblog/exporter"
+	"syblog/logger"
+	"syblog/service"
+
+	"github.com/pkg/errors"
+)
+
+// 关系图的输出位置
+const (
+	GraphStatic = "static" // 输出到静态文件目录，可通过 /graph.json 访问
+	GraphData   = "data"   // 输出到数据目录，可在模板中读取
+)
+
+// 关系图中边的类型
+const (
+	EdgeRef   = "ref"   // 引用或链接
+	EdgeEmbed = "embed" // 嵌入
+)
+
+// graphNode 描述了关系图中的一篇文章
+type graphNode struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	URL   string   `json:"url"`
+	Tags  []string `json:"tags"`
+}
+
+// graphEdge 描述了关系图中两篇文章之间的关系
+type graphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+type graph struct {
+	Nodes []*graphNode `json:"nodes"`
+	Edges []*graphEdge `json:"edges"`
+}
+
+// writeGraph 将已发布文章之间的引用和嵌入关系写入 graph.json，只包含已发布的文章
+func writeGraph(exp exporter.Exporter, articles *service.ArticleList) {
+	var dir string
+	switch config.GetConfig().Hugo.Graph {
+	case GraphStatic:
+		dir = exp.StaticPath()
+	case GraphData:
+		dir = exp.DataPath()
+	default:
+		return
+	}
+
+	g := &graph{Nodes: make([]*graphNode, 0), Edges: make([]*graphEdge, 0)}
+	exist := make(map[string]bool)
+	addEdge := func(source, target, typ string) {
+		key := source + target + typ
+		if source == target || exist[key] || !articles.Exist(target) {
+			return
+		}
+		exist[key] = true
+		g.Edges = append(g.Edges, &graphEdge{Source: source, Target: target, Type: typ})
+	}
+	for e := articles.Front(); e != nil; e = e.Next() {
+		article := e.Value.(*service.Article)
+		g.Nodes = append(g.Nodes, &graphNode{
+			ID:    article.ID,
+			Title: article.Title,
+			URL:   service.ArticleLink(article),
+			Tags:  article.Tags,
+		})
+		for _, id := range article.Linked {
+			addEdge(article.ID, id, EdgeRef)
+		}
+		for _, id := range service.FindEmbedRootIDs(article.ID) {
+			addEdge(article.ID, id, EdgeEmbed)
+		}
+	}
+
+	bs, err := json.Marshal(g)
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	if err = os.WriteFile(filepath.Join(dir, "graph.json"), bs, 0644); err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+}
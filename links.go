@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syblog/config"
+	"syblog/exporter"
+	"syblog/logger"
+	"syblog/service"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// 反链和正向链接的输出方式
+const (
+	LinksFrontMatter = "frontmatter" // 输出到 Front Matter 的 backlinks 和 links
+	LinksData        = "data"        // 输出到数据目录的 backlinks.json，键为文章地址
+	LinksNone        = "none"        // 不输出
+)
+
+// defaultLinksTemplate 为文章末尾反链列表的默认模板
+const defaultLinksTemplate = "{{if .Backlinks}}\r\n\r\n---\r\n\r\n反链：\r\n\r\n{{range $i, $l := .Backlinks}}{{inc $i}}. [{{$l.Title}}]({{$l.URL}})\r\n" +
+	"{{range $l.Contexts}}\r\n    > {{.Excerpt}} [↪]({{.URL}})\r\n{{end}}{{end}}{{end}}"
+
+// articleLinks 描述了文章的反链、正向链接、提及和相关文章
+type articleLinks struct {
+	Backlinks []service.Link `json:"backlinks"`
+	Links     []service.Link `json:"links"`
+	Mentions  []service.Link `json:"mentions,omitempty"`
+	Related   []service.Link `json:"related,omitempty"`
+}
+
+// findArticleLinks 查询文章的反链、文章中指向其他已发布文章的链接，开启 mentions 时还会查询提及
+func findArticleLinks(article *service.Article, articles *service.ArticleList) *articleLinks {
+	ret := &articleLinks{
+		Backlinks: service.FindLinkTo(article.ID, articles),
+		Links:     make([]service.Link, 0),
+	}
+	exist := make(map[string]bool)
+	for _, id := range article.Linked {
+		a := articles.Get(id)
+		if a == nil || id == article.ID || exist[id] {
+			continue
+		}
+		exist[id] = true
+		ret.Links = append(ret.Links, service.Link{Title: a.Title, URL: service.ArticleLink(a)})
+	}
+	if config.GetConfig().Hugo.Mentions {
+		ret.Mentions = service.FindMentions(article, articles)
+	}
+	return ret
+}
+
+// setLinksFrontMatter 将反链和正向链接输出到 Front Matter
+func setLinksFrontMatter(links *articleLinks, fmMap map[string]any) {
+	if config.GetConfig().Hugo.Backlinks != "" && config.GetConfig().Hugo.Backlinks != LinksFrontMatter {
+		return
+	}
+	if len(links.Backlinks) > 0 {
+		fmMap["backlinks"] = linkMaps(links.Backlinks)
+	}
+	if len(links.Links) > 0 {
+		fmMap["links"] = linkMaps(links.Links)
+	}
+	if len(links.Mentions) > 0 {
+		fmMap["mentions"] = linkMaps(links.Mentions)
+	}
+}
+
+// linkMaps 将链接转换为 Front Matter 中的表数组
+func linkMaps(links []service.Link) []map[string]any {
+	ret := make([]map[string]any, 0, len(links))
+	for _, l := range links {
+		m := map[string]any{"title": l.Title, "url": l.URL}
+		if len(l.Contexts) > 0 {
+			contexts := make([]map[string]any, 0, len(l.Contexts))
+			for _, c := range l.Contexts {
+				contexts = append(contexts, map[string]any{"excerpt": c.Excerpt, "url": c.URL})
+			}
+			m["contexts"] = contexts
+		}
+		ret = append(ret, m)
+	}
+	return ret
+}
+
+// renderLinks 按照配置的模板生成文章末尾的反链列表，未开启时返回空串
+func renderLinks(links *articleLinks) string {
+	if !config.GetConfig().Hugo.BacklinksInline {
+		return ""
+	}
+	text := defaultLinksTemplate
+	if p := config.GetConfig().Hugo.BacklinksTemplate; p != "" {
+		bs, err := os.ReadFile(p)
+		if err != nil {
+			logger.Fatalf("%+v", errors.Wrapf(err, "反链模板读取失败：%s", p))
+		}
+		text = string(bs)
+	}
+	tpl, err := template.New("backlinks").Funcs(template.FuncMap{
+		"inc": func(i int) int { return i + 1 },
+	}).Parse(text)
+	if err != nil {
+		logger.Fatalf("%+v", errors.Wrap(err, "反链模板解析失败"))
+	}
+	buf := bytes.Buffer{}
+	if err = tpl.Execute(&buf, links); err != nil {
+		logger.Fatalf("%+v", errors.Wrap(err, "反链模板执行失败"))
+	}
+	return buf.String()
+}
+
+// writeLinksData 将所有文章的反链和正向链接写入数据目录的 backlinks.json
+func writeLinksData(exp exporter.Exporter, data map[string]*articleLinks) {
+	if config.GetConfig().Hugo.Backlinks != LinksData {
+		return
+	}
+	bs, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	if err = os.MkdirAll(exp.DataPath(), 0755); err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	if err = os.WriteFile(filepath.Join(exp.DataPath(), "backlinks.json"), bs, 0644); err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+}
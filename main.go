@@ -6,21 +6,20 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"syblog/config"
+	"syblog/exporter"
 	"syblog/logger"
 	"syblog/render"
 	"syblog/service"
-	"time"
 
 	"github.com/88250/lute"
 	"github.com/88250/lute/parse"
 	"github.com/88250/lute/util"
 	"github.com/dustin/go-humanize"
-	"github.com/pelletier/go-toml/v2"
 	"github.com/pkg/errors"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
@@ -30,12 +29,12 @@ func main() {
 	logger.Info("读取配置")
 	logger.Infof("思源笔记API地址：%s", config.GetConfig().SY.APIURL)
 	logger.Infof("工作空间路径：%s", config.GetConfig().SY.WorkspacePath)
-	hugoContentPath := filepath.Join(config.GetConfig().Hugo.BlogPath, "content", config.GetConfig().Hugo.SectionName)
-	logger.Infof("清理Hugo目录：%s", hugoContentPath)
-	os.RemoveAll(hugoContentPath)
+	exp := exporter.GetExporter(config.GetConfig().Hugo.Target)
+	logger.Infof("站点生成器：%s", exp.Name())
 	logger.Info("获取需要发布的文章列表")
 	articles := service.FindArticleList()
 	logger.Infof("需要发布的直接文章数：%d", articles.Len())
+	emojis := service.FindCustomEmojis()
 	logger.Info("开始搜索关联文章")
 	for e := articles.Front(); e != nil; e = e.Next() {
 		article := e.Value.(*service.Article)
@@ -45,30 +44,55 @@ func main() {
 			continue
 		}
 		luteEngine := lute.New()
+		luteEngine.PutEmojis(emojis)
+		luteEngine.ParseOptions.KramdownBlockIAL = true
+		luteEngine.ParseOptions.KramdownSpanIAL = true
+		luteEngine.ParseOptions.Mark = true
+		luteEngine.ParseOptions.Sup = true
+		luteEngine.ParseOptions.Sub = true
+		luteEngine.ParseOptions.ToC = true
+		luteEngine.ParseOptions.FileAnnotationRef = true
 		tree := parse.Parse("", []byte(md), luteEngine.ParseOptions)
 		luteEngine.RenderOptions.AutoSpace = true
 		luteEngine.RenderOptions.FixTermTypo = true
-		renderer := render.NewFormatRenderer(tree, luteEngine.RenderOptions, article, articles)
+		luteEngine.RenderOptions.KramdownBlockIAL = true
+		renderer := render.NewFormatRenderer(tree, luteEngine.RenderOptions, article, articles, exp.Syntax())
 		formattedBytes := renderer.Render()
 		md = util.BytesToStr(formattedBytes)
 		article.Content = md
 	}
 	logger.Infof("总共需要发布的文章数：%d", articles.Len())
+	for _, p := range exp.CleanPaths(articles) {
+		logger.Infof("清理目录：%s", p)
+		os.RemoveAll(p)
+	}
+	if err := exp.Prepare(articles); err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
 
 	logger.Info("开始发布文章")
+	linksData := make(map[string]*articleLinks)
+	related := findRelated(articles)
+	series := findSeries(articles)
 	for e := articles.Front(); e != nil; e = e.Next() {
 		article := e.Value.(*service.Article)
 		logger.Infof("开始发布：%s", article.Title)
-		exportArticle(article, articles)
+		links := findArticleLinks(article, articles)
+		links.Related = related[article.ID]
+		linksData[service.ArticleLink(article)] = links
+		exportArticle(exp, article, links, series[article.ID])
 		logger.Infof("完成发布：%s", article.Title)
 	}
+	writeLinksData(exp, linksData)
+	writeGraph(exp, articles)
+	writeSearchIndex(exp, articles)
+	if err := exporter.SaveManifests(); err != nil {
+		logger.Fatalf("%+v", err)
+	}
 	logger.Info("发布文章结束")
 
-	logger.Info("执行Hugo生成站点")
-	cmd := exec.Command(config.GetConfig().Hugo.ExcutePath)
-	cmd.Dir = config.GetConfig().Hugo.BlogPath
-	cmd.Stdout = os.Stdout
-	err := cmd.Run()
+	logger.Infof("执行%s生成站点", exp.Name())
+	err := exp.Build()
 	if err != nil {
 		logger.Fatalf("%+v", errors.WithStack(err))
 	}
@@ -77,7 +101,7 @@ func main() {
 		return
 	}
 	logger.Info("打包压缩站点")
-	tempFilePath := packageSite()
+	tempFilePath := packageSite(exp.PublicPath())
 	defer os.RemoveAll(filepath.Join(tempFilePath, "../"))
 
 	logger.Info("连接服务器SFTP")
@@ -154,7 +178,7 @@ func main() {
 	logger.Info("执行完成")
 }
 
-func packageSite() string {
+func packageSite(publicPath string) string {
 	tempDir, err := os.MkdirTemp("", "sitezip-*")
 	if err != nil {
 		logger.Fatalf("%+v", errors.WithStack(err))
@@ -168,7 +192,6 @@ func packageSite() string {
 	defer gw.Close()
 	tw := tar.NewWriter(gw)
 	defer tw.Close()
-	publicPath := filepath.Join(config.GetConfig().Hugo.BlogPath, "public")
 	fis, err := os.ReadDir(publicPath)
 	if err != nil {
 		logger.Fatalf("%+v", errors.WithStack(err))
@@ -187,56 +210,55 @@ func packageSite() string {
 	return tempFile.Name()
 }
 
-func exportArticle(article *service.Article, articles *service.ArticleList) {
+func exportArticle(exp exporter.Exporter, article *service.Article, links *articleLinks, series *articleSeries) {
 	fmMap := make(map[string]any)
 	fmMap["title"] = article.Title
-	fmMap["date"] = tomlLocalDateTime(article.Created)
-	fmMap["lastmod"] = tomlLocalDateTime(article.Updated)
+	fmMap["date"] = article.Created
+	fmMap["lastmod"] = article.Updated
 	fmMap["tags"] = article.Tags
+	if article.ToC {
+		fmMap["toc"] = true
+	}
+	if article.Draft {
+		fmMap["draft"] = true
+	}
+	if !article.PublishDate.IsZero() {
+		fmMap["publishDate"] = article.PublishDate
+	}
+	if !article.ExpiryDate.IsZero() {
+		fmMap["expiryDate"] = article.ExpiryDate
+	}
+	exportDocImages(article, fmMap)
+	setLinksFrontMatter(links, fmMap)
+	setSummaryFrontMatter(article, fmMap)
+	setRelatedFrontMatter(links, fmMap)
+	setSeriesFrontMatter(series, fmMap)
 	attrs := service.FindAttrs(article.ID)
 	for k, v := range attrs {
-		if k == "date" || k == "lastmod" {
-			fmMap[k] = tomlLocalDateTime(v.(time.Time))
-		} else {
-			fmMap[k] = v
-		}
+		setFrontMatter(fmMap, k, v)
 	}
-	frontMatter, err := toml.Marshal(fmMap)
+	exp.FrontMatter(article, fmMap)
+	frontMatter, err := exporter.MarshalFrontMatter(exp.FrontMatterFormat(), fmMap)
 	if err != nil {
 		logger.Fatalf("%+v", errors.Wrap(err, ""))
 	}
-	articleDirPath := filepath.Join(config.GetConfig().Hugo.BlogPath, "content", config.GetConfig().Hugo.SectionName, article.Title)
-	if _, err := os.Stat(articleDirPath); err != nil {
-		os.MkdirAll(articleDirPath, 0555)
+	mdFilePath := exp.ArticlePath(article)
+	if _, err := os.Stat(filepath.Dir(mdFilePath)); err != nil {
+		os.MkdirAll(filepath.Dir(mdFilePath), 0755)
 	}
-	mdFilePath := filepath.Join(articleDirPath, "index.md")
 	file, err := os.Create(mdFilePath)
 	if err != nil {
 		logger.Fatalf("%+v", errors.Wrap(err, ""))
 	}
 	defer file.Close()
-	file.Write([]byte("+++\r\n"))
+	exporter.Written(mdFilePath)
 	file.Write(frontMatter)
-	file.Write([]byte("+++\r\n\r\n"))
 
 	file.WriteString(article.Content)
-
-	links := service.FindLinkTo(article.ID, articles)
-	if len(links) > 0 {
-		file.WriteString("\r\n\r\n---\r\n\r\n反链：\r\n\r\n")
-		for i, l := range links {
-			file.WriteString(strconv.Itoa(i + 1))
-			file.WriteString(". ")
-			file.WriteString("[")
-			file.WriteString(l[0])
-			file.WriteString("](")
-			file.WriteString(l[1])
-			file.WriteString(")\r\n")
-		}
-	}
+	file.WriteString(renderLinks(links))
 
 	// 输出资源文件
-	assertDirPath := filepath.Join(articleDirPath, "assets")
+	assertDirPath := exp.AssetsPath(article)
 	isFirst := true
 	for _, a := range article.Asserts {
 		p := filepath.Join(config.GetConfig().SY.AssetsPath, a)
@@ -250,21 +272,111 @@ func exportArticle(article *service.Article, articles *service.ArticleList) {
 
 		if isFirst {
 			if _, err := os.Stat(assertDirPath); err != nil {
-				os.MkdirAll(assertDirPath, 0555)
+				os.MkdirAll(assertDirPath, 0755)
 			} else {
 				os.RemoveAll(assertDirPath)
-				os.Mkdir(assertDirPath, 0555)
+				os.Mkdir(assertDirPath, 0755)
 			}
+			exporter.Written(assertDirPath)
 			isFirst = false
 		}
 
+		// PDF 标注等资源可能位于 assets 的子目录中
 		p = filepath.Join(assertDirPath, a)
+		os.MkdirAll(filepath.Dir(p), 0755)
 		dst, err := os.Create(p)
 		if err != nil {
+			src.Close()
 			logger.Fatalf("%+v", errors.Wrap(err, ""))
 		}
 
 		io.Copy(dst, src)
+		src.Close()
+		dst.Close()
+	}
+
+	// 输出自定义表情
+	for _, e := range article.Emojis {
+		src, err := os.Open(filepath.Join(config.GetConfig().SY.EmojisPath, e))
+		if err != nil {
+			logger.Errorf("%+v", errors.WithStack(err))
+			continue
+		}
+		p := filepath.Join(exp.StaticPath(), "emojis", e)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		dst, err := os.Create(p)
+		if err != nil {
+			src.Close()
+			logger.Fatalf("%+v", errors.Wrap(err, ""))
+		}
+		io.Copy(dst, src)
+		src.Close()
+		dst.Close()
+	}
+}
+
+// setFrontMatter 按照以.分隔的键路径设置 Front Matter 的值，中间层级不存在时创建嵌套表
+func setFrontMatter(fmMap map[string]any, key string, value any) {
+	keys := strings.Split(key, ".")
+	m := fmMap
+	for _, k := range keys[:len(keys)-1] {
+		sub, ok := m[k].(map[string]any)
+		if !ok {
+			sub = make(map[string]any)
+			m[k] = sub
+		}
+		m = sub
+	}
+	m[keys[len(keys)-1]] = value
+}
+
+var cssURLRegexp = regexp.MustCompile(`url\(["']?([^"')]+)["']?\)`)
+
+// exportDocImages 将文档图标和题头图输出到 Front Matter，并记录需要复制的图片。
+// 题头图为渐变色或纯色时输出为 cover.color，供主题作为备用背景色。
+func exportDocImages(article *service.Article, fmMap map[string]any) {
+	attrs := service.FindDocAttrs(article.ID)
+	if icon := attrs["icon"]; icon != "" {
+		if strings.Contains(icon, ".") {
+			// 自定义表情图片
+			fmMap["icon"] = "/emojis/" + icon
+			article.Emojis = append(article.Emojis, icon)
+		} else {
+			var b strings.Builder
+			for _, code := range strings.Split(icon, "-") {
+				r, err := strconv.ParseInt(code, 16, 32)
+				if err != nil {
+					logger.Errorf("%+v", errors.Wrapf(err, "文档图标解析失败：%s", icon))
+					b.Reset()
+					break
+				}
+				b.WriteRune(rune(r))
+			}
+			if b.Len() > 0 {
+				fmMap["icon"] = b.String()
+			}
+		}
+	}
+
+	titleImg := attrs["title-img"]
+	if titleImg == "" {
+		return
+	}
+	if m := cssURLRegexp.FindStringSubmatch(titleImg); m != nil {
+		img := m[1]
+		if strings.HasPrefix(img, "assets/") {
+			article.Asserts = append(article.Asserts, strings.TrimPrefix(img, "assets/"))
+		}
+		fmMap["cover"] = map[string]any{"image": img}
+		fmMap["featured_image"] = img
+		return
+	}
+	for _, decl := range strings.Split(titleImg, ";") {
+		kv := strings.SplitN(decl, ":", 2)
+		if len(kv) == 2 && strings.HasPrefix(strings.TrimSpace(kv[0]), "background") {
+			fmMap["cover"] = map[string]any{"color": strings.TrimSpace(kv[1])}
+			return
+		}
 	}
 }
 
@@ -309,18 +421,3 @@ func compress(file *os.File, prefix string, tw *tar.Writer) error {
 	}
 	return nil
 }
-
-func tomlLocalDateTime(t time.Time) toml.LocalDateTime {
-	return toml.LocalDateTime{
-		LocalDate: toml.LocalDate{
-			Year:  t.Year(),
-			Month: int(t.Month()),
-			Day:   t.Day(),
-		},
-		LocalTime: toml.LocalTime{
-			Hour:   t.Hour(),
-			Minute: t.Minute(),
-			Second: t.Second(),
-		},
-	}
-}
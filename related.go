@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sort"
+	"syblog/config"
+	"syblog/service"
+)
+
+// 相关文章的计分规则
+const (
+	relatedTagScore      = 3 // 每个相同的标签
+	relatedRefScore      = 2 // 每个共同引用的文档
+	relatedNeighborScore = 4 // 两篇文章之间直接引用
+	relatedDistantScore  = 1 // 两篇文章通过另一篇文章间接引用，每条路径计一次
+)
+
+// findRelated 按相同的标签、共同引用的文档和引用关系中的距离为每篇文章计算相关文章，
+// 按分数从高到低取前 relatedCount 篇，分数相同时较新的文章在前，只包含已发布的文章
+func findRelated(articles *service.ArticleList) map[string][]service.Link {
+	ret := make(map[string][]service.Link)
+	if !config.GetConfig().Hugo.Related {
+		return ret
+	}
+
+	// 已发布文章之间的引用关系，不区分方向
+	neighbors := make(map[string]map[string]bool)
+	addEdge := func(a, b string) {
+		if neighbors[a] == nil {
+			neighbors[a] = make(map[string]bool)
+		}
+		neighbors[a][b] = true
+	}
+	tagged := make(map[string][]string)
+	referrers := make(map[string][]string)
+	for e := articles.Front(); e != nil; e = e.Next() {
+		article := e.Value.(*service.Article)
+		for _, id := range article.Linked {
+			if id != article.ID && articles.Exist(id) {
+				addEdge(article.ID, id)
+				addEdge(id, article.ID)
+			}
+		}
+		for _, tag := range article.Tags {
+			tagged[tag] = append(tagged[tag], article.ID)
+		}
+		for _, id := range service.FindRefRootIDs(article.ID) {
+			referrers[id] = append(referrers[id], article.ID)
+		}
+	}
+	refs := make(map[string][]string)
+	for target, ids := range referrers {
+		for _, id := range ids {
+			refs[id] = append(refs[id], target)
+		}
+	}
+
+	for e := articles.Front(); e != nil; e = e.Next() {
+		article := e.Value.(*service.Article)
+		scores := make(map[string]int)
+		for _, tag := range article.Tags {
+			for _, id := range tagged[tag] {
+				scores[id] += relatedTagScore
+			}
+		}
+		for _, target := range refs[article.ID] {
+			for _, id := range referrers[target] {
+				scores[id] += relatedRefScore
+			}
+		}
+		for id := range neighbors[article.ID] {
+			scores[id] += relatedNeighborScore
+			for distant := range neighbors[id] {
+				if !neighbors[article.ID][distant] {
+					scores[distant] += relatedDistantScore
+				}
+			}
+		}
+		delete(scores, article.ID)
+
+		related := make([]*service.Article, 0, len(scores))
+		for id := range scores {
+			related = append(related, articles.Get(id))
+		}
+		sort.Slice(related, func(i, j int) bool {
+			a, b := related[i], related[j]
+			if scores[a.ID] != scores[b.ID] {
+				return scores[a.ID] > scores[b.ID]
+			}
+			if !a.Updated.Equal(b.Updated) {
+				return a.Updated.After(b.Updated)
+			}
+			return a.ID < b.ID
+		})
+		if len(related) > config.GetConfig().Hugo.RelatedCount {
+			related = related[:config.GetConfig().Hugo.RelatedCount]
+		}
+		links := make([]service.Link, 0, len(related))
+		for _, a := range related {
+			links = append(links, service.Link{Title: a.Title, URL: service.ArticleLink(a)})
+		}
+		ret[article.ID] = links
+	}
+	return ret
+}
+
+// setRelatedFrontMatter 将相关文章输出到 Front Matter 的 related
+func setRelatedFrontMatter(links *articleLinks, fmMap map[string]any) {
+	if len(links.Related) > 0 {
+		fmMap["related"] = linkMaps(links.Related)
+	}
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"syblog/config"
+	"syblog/service"
+	"unicode"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// 标题锚点的生成方式
+const (
+	AnchorText = "text" // 由标题文本生成，与 Hugo 默认规则一致
+	AnchorID   = "id"   // 使用思源笔记的块ID，修改标题后锚点保持不变
+)
+
+// resolveHeadingIDs 确定各个标题在思源笔记中的块ID。
+// 导出的 Markdown 带有 IAL 时直接使用其中的 id，否则按标题级别和文本与文档中的标题块依次匹配。
+func (r *FormatRenderer) resolveHeadingIDs() {
+	r.headingIDs = make(map[*ast.Node]string)
+	var unresolved []*ast.Node
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeHeading != n.Type {
+			return ast.WalkContinue
+		}
+		if id := n.IALAttr("id"); id != "" {
+			r.headingIDs[n] = id
+		} else {
+			unresolved = append(unresolved, n)
+		}
+		return ast.WalkSkipChildren
+	})
+	if len(unresolved) == 0 {
+		return
+	}
+
+	candidates := make(map[string][]string)
+	for _, b := range service.FindBlocks(r.article, "h") {
+		key := b.SubType + "\n" + strings.TrimSpace(b.Content)
+		candidates[key] = append(candidates[key], b.ID)
+	}
+	for _, n := range unresolved {
+		key := "h" + strconv.Itoa(n.HeadingLevel) + "\n" + strings.TrimSpace(PlainText(n))
+		if ids := candidates[key]; len(ids) > 0 {
+			r.headingIDs[n] = ids[0]
+			candidates[key] = ids[1:]
+		}
+	}
+}
+
+// headingAnchors 计算文档中各个标题的锚点。
+// 优先使用自定义的标题 ID，其次在配置为 id 时使用块ID，被引用的标题使用块ID，其余按 Hugo 默认的 github 风格生成，
+// 重复的锚点会追加序号。
+func (r *FormatRenderer) headingAnchors() map[*ast.Node]string {
+	if nil == r.anchors {
+		r.anchors = walkHeadingAnchors(r.Tree.Root, func(n *ast.Node) string {
+			if id := r.headingIDs[n]; id != "" && (config.GetConfig().Hugo.HeadingAnchor == AnchorID || r.refIDs[id]) {
+				return service.BlockAnchor(id, true)
+			}
+			return githubAnchor(PlainText(n))
+		})
+	}
+	return r.anchors
+}
+
+// AnchorHeadings 为渲染 HTML 的语法树设置标题锚点，规则与导出到 Hugo 时一致：
+// 带有 {#id} 的标题使用自定义 ID，其余按 github 风格生成，重复的锚点会追加序号。
+func AnchorHeadings(tree *parse.Tree) {
+	for n, anchor := range walkHeadingAnchors(tree.Root, func(n *ast.Node) string {
+		return githubAnchor(PlainText(n))
+	}) {
+		n.HeadingNormalizedID = anchor
+	}
+}
+
+// walkHeadingAnchors 按文档顺序为各个标题分配锚点：带有 {#id} 的标题使用自定义 ID，其余使用 anchor 返回的锚点，
+// 重复的锚点按 Hugo 的规则追加 -1、-2 等序号
+func walkHeadingAnchors(root *ast.Node, anchor func(n *ast.Node) string) map[*ast.Node]string {
+	ret := make(map[*ast.Node]string)
+	occurs := make(map[string]int)
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeHeading != n.Type {
+			return ast.WalkContinue
+		}
+		var a string
+		if headingID := n.ChildByType(ast.NodeHeadingID); nil != headingID {
+			a = strings.TrimLeft(util.BytesToStr(headingID.Tokens), "#")
+		} else {
+			a = anchor(n)
+		}
+		if c := occurs[a]; c > 0 {
+			occurs[a] = c + 1
+			a += "-" + strconv.Itoa(c)
+		} else {
+			occurs[a] = 1
+		}
+		ret[n] = a
+		return ast.WalkSkipChildren
+	})
+	return ret
+}
+
+// headingAttrs 返回标题行末的 Goldmark 属性，锚点与生成器自动生成的一致且没有其他属性时返回空串。
+// 生成器不支持属性时锚点由 renderHeading 输出为 HTML 元素。
+func (r *FormatRenderer) headingAttrs(node *ast.Node) string {
+	if AttrsNone == r.Syntax.Attrs {
+		return ""
+	}
+	var attrs []string
+	if anchor := r.headingAnchors()[node]; !r.Syntax.AutoAnchor || anchor != githubAnchor(PlainText(node)) {
+		attrs = append(attrs, "#"+anchor)
+	}
+	if !r.withoutKramdownBlockIAL(node) {
+		ial := util.BytesToStr(node.Next.Tokens)
+		attrs = append(attrs, strings.TrimSuffix(strings.TrimPrefix(ial, "{"), "}"))
+	}
+	if len(attrs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(attrs, " ") + "}"
+}
+
+// githubAnchor 将标题文本转换为 github 风格的锚点：转为小写，空格替换为 -，去掉除 - 和 _ 以外的标点
+func githubAnchor(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	var b strings.Builder
+	for _, c := range text {
+		switch {
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '_':
+			b.WriteRune(c)
+		case unicode.IsSpace(c):
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
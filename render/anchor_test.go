@@ -0,0 +1,41 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestGithubAnchor(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Go 1.19 发布  ", "go-119-发布"},
+		{"C++ & Rust?", "c--rust"},
+		{"snake_case-name", "snake_case-name"},
+		{"标题：中文，标点！", "标题中文标点"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := githubAnchor(tt.text); got != tt.want {
+			t.Errorf("githubAnchor(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	md := "## 第一节 {#intro}\n\n## Code `x` here\n\n## Code `x` here\n\n## intro\n"
+	tests := []struct {
+		syntax *Syntax
+		want   string
+	}{
+		// Hugo 会自动生成与之相同的锚点，只输出自定义和追加了序号的锚点
+		{SyntaxHugo, "## 第一节 {#intro}\n\n## Code `x` here\n\n## Code `x` here {#code-x-here-1}\n\n## intro {#intro-1}\n"},
+		{SyntaxZola, "## 第一节 {#intro}\n\n## Code `x` here {#code-x-here}\n\n## Code `x` here {#code-x-here-1}\n\n## intro {#intro-1}\n"},
+	}
+	for _, tt := range tests {
+		if got := format(md, tt.syntax); got != tt.want {
+			t.Errorf("format() with %s = %q, want %q", tt.syntax.Name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package render
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"syblog/logger"
+	"syblog/service"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/util"
+)
+
+// renderFileAnnotationRef 将 PDF 标注引用 <<assets/x.pdf/id "text">> 渲染为带有 PDF 页码链接的引文，并将 PDF 加入需要复制的资源。
+// 标注引用独占一个段落时输出为引用块，否则输出为行内引文。
+func (r *FormatRenderer) renderFileAnnotationRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+	idNode := node.ChildByType(ast.NodeFileAnnotationRefID)
+	if nil == idNode {
+		return ast.WalkSkipChildren
+	}
+	id := strings.TrimPrefix(util.BytesToStr(idNode.Tokens), "assets/")
+	pdf, annotationID := path.Split(id)
+	pdf = strings.TrimSuffix(pdf, "/")
+	text := ""
+	if textNode := node.ChildByType(ast.NodeFileAnnotationRefText); nil != textNode {
+		text = util.BytesToStr(textNode.Tokens)
+	}
+
+	page := 1
+	annotation, err := service.FindAnnotation(pdf, annotationID)
+	if err != nil {
+		logger.Warnf("%+v", err)
+	} else {
+		page = annotation.Page()
+		if annotation.Content != "" {
+			text = annotation.Content
+		}
+	}
+	if text == "" {
+		text = pdf
+	}
+	r.article.Asserts = append(r.article.Asserts, pdf)
+	link := "[" + pdf + " p." + strconv.Itoa(page) + "](assets/" + pdf + "#page=" + strconv.Itoa(page) + ")"
+
+	if nil != node.Parent && ast.NodeParagraph == node.Parent.Type && node.Parent.FirstChild == node && nil == node.Next {
+		for _, line := range strings.Split(text, "\n") {
+			r.WriteString("> " + line + "\n")
+		}
+		r.WriteString(">\n> —— " + link)
+	} else {
+		r.WriteString("<q>" + strings.ReplaceAll(text, "\n", " ") + "</q>（" + link + "）")
+	}
+	return ast.WalkSkipChildren
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"strings"
+)
+
+// Dialect 描述了目标站点生成器能够识别的行级标记，Markers 的键为思源笔记中的标记类型，值为开始和结束标记符。
+type Dialect struct {
+	Name    string
+	Markers map[string][2]string
+}
+
+var (
+	// DialectHTML 将 Goldmark 等解析器不支持的扩展标记输出为 HTML 标签
+	DialectHTML = &Dialect{
+		Name: "html",
+		Markers: map[string][2]string{
+			"mark":   {"<mark>", "</mark>"},
+			"u":      {"<u>", "</u>"},
+			"sup":    {"<sup>", "</sup>"},
+			"sub":    {"<sub>", "</sub>"},
+			"kbd":    {"<kbd>", "</kbd>"},
+			"strong": {"<strong>", "</strong>"},
+			"em":     {"<em>", "</em>"},
+			"s":      {"<s>", "</s>"},
+			"code":   {"<code>", "</code>"},
+		},
+	}
+
+	// DialectSiYuan 保留思源笔记的扩展标记语法
+	DialectSiYuan = &Dialect{
+		Name: "siyuan",
+		Markers: map[string][2]string{
+			"mark":   {"==", "=="},
+			"u":      {"<u>", "</u>"},
+			"sup":    {"^", "^"},
+			"sub":    {"~", "~"},
+			"kbd":    {"<kbd>", "</kbd>"},
+			"strong": {"**", "**"},
+			"em":     {"*", "*"},
+			"s":      {"~~", "~~"},
+			"code":   {"`", "`"},
+		},
+	}
+)
+
+// GetDialect 根据名称获取方言，名称为空或未知时返回 DialectHTML
+func GetDialect(name string) *Dialect {
+	switch strings.ToLower(name) {
+	case DialectSiYuan.Name:
+		return DialectSiYuan
+	default:
+		return DialectHTML
+	}
+}
+
+// open 返回标记类型对应的开始标记符，未知类型保留为 span 标签
+func (d *Dialect) open(typ string) string {
+	if m, ok := d.Markers[typ]; ok {
+		return m[0]
+	}
+	return "<span data-type=\"" + typ + "\">"
+}
+
+// close 返回标记类型对应的结束标记符
+func (d *Dialect) close(typ string) string {
+	if m, ok := d.Markers[typ]; ok {
+		return m[1]
+	}
+	return "</span>"
+}
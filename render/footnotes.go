@@ -0,0 +1,84 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/util"
+)
+
+// normalizeFootnotes 按引用出现的顺序将脚注重新编号为 ^1、^2……，并将所有脚注定义移动到文档末尾。
+// 未被引用的脚注定义会被丢弃。
+func (r *FormatRenderer) normalizeFootnotes() {
+	defs := make(map[string]*ast.Node)
+	var defBlocks []*ast.Node
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeFootnotesDefBlock:
+			defBlocks = append(defBlocks, n)
+		case ast.NodeFootnotesDef:
+			label := footnoteLabel(n.Tokens)
+			if _, ok := defs[label]; !ok {
+				defs[label] = n
+			}
+		}
+		return ast.WalkContinue
+	})
+	if len(defBlocks) == 0 {
+		return
+	}
+
+	numbers := make(map[string]string)
+	var ordered []*ast.Node
+	renumber := func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeFootnotesRef != n.Type {
+			return ast.WalkContinue
+		}
+		label := footnoteLabel(n.Tokens)
+		num, ok := numbers[label]
+		if !ok {
+			def, exist := defs[label]
+			if !exist {
+				return ast.WalkContinue
+			}
+			num = "^" + strconv.Itoa(len(ordered)+1)
+			numbers[label] = num
+			ordered = append(ordered, def)
+		}
+		n.Tokens = []byte(num)
+		return ast.WalkContinue
+	}
+	// 先为正文中的引用编号，再为脚注内部的嵌套引用编号
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if ast.NodeFootnotesDefBlock == n.Type {
+			return ast.WalkSkipChildren
+		}
+		return renumber(n, entering)
+	})
+	for i := 0; i < len(ordered); i++ {
+		ast.Walk(ordered[i], renumber)
+	}
+
+	block := &ast.Node{Type: ast.NodeFootnotesDefBlock}
+	for _, def := range ordered {
+		def.Tokens = []byte(numbers[footnoteLabel(def.Tokens)])
+		block.AppendChild(def)
+	}
+	for _, b := range defBlocks {
+		b.Unlink()
+	}
+	if nil != block.FirstChild {
+		r.Tree.Root.AppendChild(block)
+	}
+}
+
+// footnoteLabel 返回不区分大小写、不带 ^ 前缀的脚注标签
+func footnoteLabel(tokens []byte) string {
+	label := util.BytesToStr(tokens)
+	label = strings.ReplaceAll(label, util.Caret, "")
+	return strings.ToLower(strings.TrimPrefix(label, "^"))
+}
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// ref 和 backref 为 Goldmark 输出的脚注引用和返回链接
+func ref(n string) string {
+	return `<sup id="fnref:` + n + `"><a href="#fn:` + n + `" class="footnote-ref" role="doc-noteref">` + n + `</a></sup>`
+}
+
+func backref(n string) string {
+	return `<a href="#fnref:` + n + `" class="footnote-backref" role="doc-backlink">`
+}
+
+func TestFootnotes(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+		html []string // Goldmark 渲染导出结果后应包含的 HTML 片段
+	}{
+		{
+			name: "多段落",
+			md:   "正文[^a]。\n\n[^a]: 第一段\n\n    第二段\n",
+			want: "正文[^1]。\n\n[^1]: 第一段\n\n    第二段\n",
+			html: []string{ref("1"), `<li id="fn:1">`, "<p>第一段</p>", "<p>第二段&#160;" + backref("1")},
+		},
+		{
+			name: "代码块",
+			md:   "正文[^a]。\n\n[^a]:\n    ```go\n    fmt.Println()\n    ```\n",
+			want: "正文[^1]。\n\n[^1]:\n    ```go\n    fmt.Println()\n    ```\n",
+			html: []string{ref("1"), `<li id="fn:1">` + "\n" + `<pre><code class="language-go">fmt.Println()` + "\n</code></pre>", backref("1")},
+		},
+		{
+			name: "列表",
+			md:   "正文[^a]。\n\n[^a]:\n    - 一\n    - 二\n",
+			want: "正文[^1]。\n\n[^1]:\n    - 一\n    - 二\n",
+			html: []string{ref("1"), `<li id="fn:1">` + "\n<ul>\n<li>一</li>\n<li>二</li>\n</ul>", backref("1")},
+		},
+		{
+			name: "按引用顺序编号",
+			md:   "甲[^b]乙[^a]丙[^b]。\n\n[^a]: A\n[^b]: B\n",
+			want: "甲[^1]乙[^2]丙[^1]。\n\n[^1]: B\n\n[^2]: A\n",
+			html: []string{"甲" + ref("1") + "乙" + ref("2"), `<li id="fn:1">` + "\n<p>B&#160;" + backref("1"), `<li id="fn:2">` + "\n<p>A&#160;" + backref("2")},
+		},
+		{
+			name: "去掉未引用的脚注",
+			md:   "甲[^a]。\n\n[^a]: A\n[^x]: 未引用\n",
+			want: "甲[^1]。\n\n[^1]: A\n",
+			html: []string{ref("1"), `<li id="fn:1">` + "\n<p>A&#160;" + backref("1")},
+		},
+		{
+			name: "脚注中的引用",
+			md:   "甲[^a]。\n\n[^b]: B\n[^a]: A[^b]\n",
+			want: "甲[^1]。\n\n[^1]: A[^2]\n\n[^2]: B\n",
+			html: []string{ref("1"), "<p>A" + ref("2") + "&#160;" + backref("1"), `<li id="fn:2">` + "\n<p>B&#160;" + backref("2")},
+		},
+	}
+	md := goldmark.New(goldmark.WithExtensions(extension.Footnote))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := format(tt.md, SyntaxHugo)
+			if got != tt.want {
+				t.Fatalf("format() = %q, want %q", got, tt.want)
+			}
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(got), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			if !strings.Contains(out, `<div class="footnotes" role="doc-endnotes">`) {
+				t.Fatalf("goldmark output has no footnote list:\n%s", out)
+			}
+			for _, h := range tt.html {
+				if !strings.Contains(out, h) {
+					t.Errorf("goldmark output missing %q:\n%s", h, out)
+				}
+			}
+			if strings.Contains(out, "[^") {
+				t.Errorf("goldmark output has unresolved footnote syntax:\n%s", out)
+			}
+		})
+	}
+}
@@ -2,10 +2,10 @@ package render
 
 import (
 	"bytes"
-	"net/url"
 	"strconv"
 	"strings"
 	"syblog/config"
+	"syblog/logger"
 	"syblog/service"
 	"unicode"
 	"unicode/utf8"
@@ -24,10 +24,15 @@ type FormatRenderer struct {
 	NodeWriterStack []*bytes.Buffer // 节点输出缓冲栈
 	article         *service.Article
 	articles        *service.ArticleList
+	refIDs          map[string]bool      // 文档中被引用的块ID
+	Dialect         *Dialect             // 目标站点生成器的 Markdown 方言
+	Syntax          *Syntax              // 目标站点生成器支持的短代码和属性语法
+	headingIDs      map[*ast.Node]string // 标题对应的块ID
+	anchors         map[*ast.Node]string // 标题锚点
 }
 
 // NewFormatRenderer 创建一个格式化渲染器。
-func NewFormatRenderer(tree *parse.Tree, options *render.Options, article *service.Article, articles *service.ArticleList) *FormatRenderer {
+func NewFormatRenderer(tree *parse.Tree, options *render.Options, article *service.Article, articles *service.ArticleList, syntax *Syntax) *FormatRenderer {
 	ret := &FormatRenderer{BaseRenderer: render.NewBaseRenderer(tree, options)}
 	ret.RendererFuncs[ast.NodeDocument] = ret.renderDocument
 	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
@@ -164,6 +169,14 @@ func NewFormatRenderer(tree *parse.Tree, options *render.Options, article *servi
 	ret.RendererFuncs[ast.NodeTextMarkCloseMarker] = ret.renderTextMarkCloseMarker
 	ret.article = article
 	ret.articles = articles
+	ret.refIDs = service.FindRefBlockIDs(article.ID)
+	ret.Dialect = GetDialect(config.GetConfig().Hugo.Dialect)
+	ret.Syntax = syntax
+	ret.resolveHeadingIDs()
+	ret.convertEmbeds()
+	ret.markSummary()
+	ret.translateIAL()
+	ret.normalizeFootnotes()
 	return ret
 }
 
@@ -173,16 +186,20 @@ func (r *FormatRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkS
 
 func (r *FormatRenderer) renderTextMarkOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("<span data-type=\"")
-		r.Write(node.Tokens)
-		r.WriteString("\">")
+		for _, typ := range strings.Fields(util.BytesToStr(node.Tokens)) {
+			r.WriteString(r.Dialect.open(typ))
+		}
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderTextMarkCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("</span>")
+		// 按开始标记相反的顺序闭合
+		types := strings.Fields(util.BytesToStr(node.Parent.FirstChild.Tokens))
+		for i := len(types) - 1; i >= 0; i-- {
+			r.WriteString(r.Dialect.close(types[i]))
+		}
 	}
 	return ast.WalkContinue
 }
@@ -200,14 +217,14 @@ func (r *FormatRenderer) renderUnderline(node *ast.Node, entering bool) ast.Walk
 
 func (r *FormatRenderer) renderUnderlineOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("<u>")
+		r.WriteString(r.Dialect.open("u"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderUnderlineCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("</u>")
+		r.WriteString(r.Dialect.close("u"))
 	}
 	return ast.WalkContinue
 }
@@ -218,14 +235,14 @@ func (r *FormatRenderer) renderKbd(node *ast.Node, entering bool) ast.WalkStatus
 
 func (r *FormatRenderer) renderKbdOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("<kbd>")
+		r.WriteString(r.Dialect.open("kbd"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderKbdCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("</kbd>")
+		r.WriteString(r.Dialect.close("kbd"))
 	}
 	return ast.WalkContinue
 }
@@ -266,7 +283,9 @@ func (r *FormatRenderer) renderIFrame(node *ast.Node, entering bool) ast.WalkSta
 		r.Write(tokens)
 		r.Newline()
 		if !r.isLastNode(r.Tree.Root, node) {
-			r.WriteByte(lex.ItemNewline)
+			if r.withoutKramdownBlockIAL(node) {
+				r.WriteByte(lex.ItemNewline)
+			}
 		}
 	}
 	return ast.WalkContinue
@@ -280,7 +299,9 @@ func (r *FormatRenderer) renderWidget(node *ast.Node, entering bool) ast.WalkSta
 		r.Write(tokens)
 		r.Newline()
 		if !r.isLastNode(r.Tree.Root, node) {
-			r.WriteByte(lex.ItemNewline)
+			if r.withoutKramdownBlockIAL(node) {
+				r.WriteByte(lex.ItemNewline)
+			}
 		}
 	}
 	return ast.WalkContinue
@@ -398,15 +419,10 @@ func (r *FormatRenderer) renderKramdownBlockIAL(node *ast.Node, entering bool) a
 		return ast.WalkContinue
 	}
 
-	if nil != node.Previous && ast.NodeListItem == node.Previous.Type {
-		return ast.WalkContinue
-	}
 	if entering {
 		r.Newline()
-		if r.Options.KramdownBlockIAL {
-			if util.IsDocIAL(node.Tokens) {
-				r.WriteByte(lex.ItemNewline)
-			}
+		// 标题的属性已经在标题行内输出
+		if nil == node.Previous || ast.NodeHeading != node.Previous.Type {
 			r.Write(node.Tokens)
 		}
 	} else {
@@ -423,13 +439,6 @@ func (r *FormatRenderer) renderKramdownBlockIAL(node *ast.Node, entering bool) a
 }
 
 func (r *FormatRenderer) renderKramdownSpanIAL(node *ast.Node, entering bool) ast.WalkStatus {
-	if !r.Options.KramdownSpanIAL {
-		return ast.WalkContinue
-	}
-
-	if entering {
-		r.Write(node.Tokens)
-	}
 	return ast.WalkContinue
 }
 
@@ -444,28 +453,28 @@ func (r *FormatRenderer) renderMark(node *ast.Node, entering bool) ast.WalkStatu
 
 func (r *FormatRenderer) renderMark1OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("=")
+		r.WriteString(r.Dialect.open("mark"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderMark1CloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("=")
+		r.WriteString(r.Dialect.close("mark"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderMark2OpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("==")
+		r.WriteString(r.Dialect.open("mark"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderMark2CloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("==")
+		r.WriteString(r.Dialect.close("mark"))
 	}
 	return ast.WalkContinue
 }
@@ -476,14 +485,14 @@ func (r *FormatRenderer) renderSup(node *ast.Node, entering bool) ast.WalkStatus
 
 func (r *FormatRenderer) renderSupOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("^")
+		r.WriteString(r.Dialect.open("sup"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderSupCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("^")
+		r.WriteString(r.Dialect.close("sup"))
 	}
 	return ast.WalkContinue
 }
@@ -494,14 +503,14 @@ func (r *FormatRenderer) renderSub(node *ast.Node, entering bool) ast.WalkStatus
 
 func (r *FormatRenderer) renderSubOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("~")
+		r.WriteString(r.Dialect.open("sub"))
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderSubCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("~")
+		r.WriteString(r.Dialect.close("sub"))
 	}
 	return ast.WalkContinue
 }
@@ -562,10 +571,6 @@ func (r *FormatRenderer) renderBlockRefDynamicText(node *ast.Node, entering bool
 	return ast.WalkContinue
 }
 
-func (r *FormatRenderer) renderFileAnnotationRef(node *ast.Node, entering bool) ast.WalkStatus {
-	return ast.WalkContinue
-}
-
 func (r *FormatRenderer) renderFileAnnotationRefID(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Write(node.Tokens)
@@ -646,21 +651,17 @@ func (r *FormatRenderer) renderBackslash(node *ast.Node, entering bool) ast.Walk
 	return ast.WalkContinue
 }
 
-func (r *FormatRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
-	if entering {
-		r.WriteString("[toc]\n\n")
-	}
-	return ast.WalkContinue
-}
-
 func (r *FormatRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("[" + util.BytesToStr(node.Tokens) + "]")
+		r.WriteString("[^" + strings.TrimPrefix(util.BytesToStr(node.Tokens), "^") + "]")
 	}
 	return ast.WalkContinue
 }
 
 func (r *FormatRenderer) renderFootnotesDefBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Newline()
+	}
 	return ast.WalkContinue
 }
 
@@ -668,24 +669,27 @@ func (r *FormatRenderer) renderFootnotesDef(node *ast.Node, entering bool) ast.W
 	if entering {
 		r.Writer = &bytes.Buffer{}
 		r.NodeWriterStack = append(r.NodeWriterStack, r.Writer)
-		r.WriteString("[" + util.BytesToStr(node.Tokens) + "]: ")
 	} else {
 		writer := r.NodeWriterStack[len(r.NodeWriterStack)-1]
 		r.NodeWriterStack = r.NodeWriterStack[:len(r.NodeWriterStack)-1]
-		buf := writer.String()
+		buf := strings.Trim(writer.String(), "\n")
 		lines := strings.Split(buf, "\n")
 		contentBuf := bytes.Buffer{}
-		for i, line := range lines {
-			if i == 0 {
-				contentBuf.WriteString(line + "\n")
+		contentBuf.WriteString("[^" + strings.TrimPrefix(util.BytesToStr(node.Tokens), "^") + "]:")
+		// 首个子块不是段落（如列表、代码块）时需要另起一行，否则会被当作脚注首行的文本
+		if nil != node.FirstChild && ast.NodeParagraph == node.FirstChild.Type {
+			contentBuf.WriteString(" " + lines[0])
+			lines = lines[1:]
+		}
+		contentBuf.WriteString("\n")
+		for _, line := range lines {
+			if line == "" {
+				contentBuf.WriteString("\n")
 			} else {
-				if line == "" {
-					contentBuf.WriteString("\n")
-				} else {
-					contentBuf.WriteString("    " + line + "\n")
-				}
+				contentBuf.WriteString("    " + line + "\n")
 			}
 		}
+		contentBuf.WriteString("\n")
 		r.NodeWriterStack[len(r.NodeWriterStack)-1].Write(contentBuf.Bytes())
 		r.Writer = r.NodeWriterStack[len(r.NodeWriterStack)-1]
 	}
@@ -700,11 +704,20 @@ func (r *FormatRenderer) renderEmojiAlias(node *ast.Node, entering bool) ast.Wal
 }
 
 func (r *FormatRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
-	return ast.WalkContinue
+	if entering {
+		r.Write(node.Tokens)
+		if m := srcAttrRegexp.FindSubmatch(node.Tokens); nil != m && bytes.HasPrefix(m[1], []byte("/emojis/")) {
+			r.article.Emojis = append(r.article.Emojis, strings.TrimPrefix(string(m[1]), "/emojis/"))
+		}
+	}
+	return ast.WalkSkipChildren
 }
 
 func (r *FormatRenderer) renderEmojiUnicode(node *ast.Node, entering bool) ast.WalkStatus {
-	return ast.WalkContinue
+	if entering {
+		r.Write(node.Tokens)
+	}
+	return ast.WalkSkipChildren
 }
 
 func (r *FormatRenderer) renderEmoji(node *ast.Node, entering bool) ast.WalkStatus {
@@ -1023,24 +1036,28 @@ func (r *FormatRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatu
 		}
 		if node.LinkType == 0 {
 			textNode := node.ChildByType(ast.NodeLinkText)
+			var text string
 			if textNode != nil {
-				text := util.BytesToStr(textNode.Tokens)
-				text = strings.Trim(text, "\"")
-				r.WriteString("[" + text + "]")
+				text = strings.Trim(util.BytesToStr(textNode.Tokens), "\"")
 			}
 			urlNode := node.ChildByType(ast.NodeLinkDest)
+			var link string
+			published := true
 			if urlNode != nil {
-				link := util.BytesToStr(urlNode.Tokens)
+				link = util.BytesToStr(urlNode.Tokens)
 				if strings.HasPrefix(link, "siyuan://blocks/") {
-					// 需要根据块ID找到对应的文章，替换为对应文章的路径，并且需要导出此文章
-					id := strings.TrimPrefix(link, "siyuan://blocks/")
-					a := service.FindArticleByBlockID(id)
-					if a != nil {
-						t := strings.ReplaceAll(a.Title, " ", "-")
-						link = "/" + config.GetConfig().Hugo.SectionName + "/" + url.QueryEscape(strings.ToLower(t)) + "/"
-						r.articles.Put(a)
-					}
+					link, published = r.resolveBlockLink(strings.TrimPrefix(link, "siyuan://blocks/"))
 				}
+			}
+			if !published {
+				// 指向不发布的文档，只保留链接文本
+				r.WriteString(text)
+				return ast.WalkSkipChildren
+			}
+			if textNode != nil {
+				r.WriteString("[" + text + "]")
+			}
+			if urlNode != nil {
 				r.WriteString("(" + link)
 				titleNode := node.ChildByType(ast.NodeLinkTitle)
 				if titleNode != nil {
@@ -1057,6 +1074,32 @@ func (r *FormatRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatu
 	return ast.WalkContinue
 }
 
+// resolveBlockLink 根据块ID找到对应的文章，返回文章的地址，并且需要导出此文章。
+// 链接到文章中的块时地址带有块的锚点，被引用的块在导出时会保留锚点。
+// 文章暂不发布或满足任一 exclude 选择器时返回 false，未找到文档时保留原链接
+func (r *FormatRenderer) resolveBlockLink(id string) (string, bool) {
+	a := service.FindArticleByBlockID(id)
+	if a == nil {
+		return "siyuan://blocks/" + id, true
+	}
+	if !r.articles.Exist(a.ID) {
+		if reason := a.HoldReason(); reason != "" {
+			logger.Infof("暂不发布《%s》：%s", a.Title, reason)
+			return "", false
+		}
+		if service.IsExcluded(a.ID) {
+			logger.Infof("不发布被排除的文档《%s》", a.Title)
+			return "", false
+		}
+	}
+	r.articles.Put(a)
+	r.article.Linked = append(r.article.Linked, a.ID)
+	if id != a.ID {
+		return service.ArticleLink(a) + "#" + service.BlockAnchor(id, "h" == service.FindBlockType(id)), true
+	}
+	return service.ArticleLink(a), true
+}
+
 func (r *FormatRenderer) renderHTML(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
@@ -1509,6 +1552,9 @@ func (r *FormatRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkSt
 			r.Write(bytes.Repeat([]byte{lex.ItemCrosshatch}, node.HeadingLevel))
 			r.WriteByte(lex.ItemSpace)
 		}
+		if AttrsNone == r.Syntax.Attrs {
+			r.WriteString(htmlAnchor("a", r.headingAnchors()[node]))
+		}
 	} else {
 		if node.HeadingSetext {
 			r.WriteByte(lex.ItemNewline)
@@ -1520,6 +1566,11 @@ func (r *FormatRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkSt
 			}
 		}
 
+		if attrs := r.headingAttrs(node); attrs != "" {
+			r.WriteByte(lex.ItemSpace)
+			r.WriteString(attrs)
+		}
+
 		if !node.ParentIs(ast.NodeTableCell) {
 			if r.withoutKramdownBlockIAL(node) {
 				r.Newline()
@@ -1535,9 +1586,7 @@ func (r *FormatRenderer) renderHeadingC8hMarker(node *ast.Node, entering bool) a
 }
 
 func (r *FormatRenderer) renderHeadingID(node *ast.Node, entering bool) ast.WalkStatus {
-	if entering {
-		r.WriteString(" {" + util.BytesToStr(node.Tokens) + "}")
-	}
+	// 自定义的标题 ID 在 renderHeading 中作为锚点输出
 	return ast.WalkContinue
 }
 
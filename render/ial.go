@@ -0,0 +1,109 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// translateIAL 将思源笔记的 kramdown 内联属性列表转换为 Goldmark 的属性语法。
+// id、updated、fold 等内部属性会被丢弃，仅保留样式、类名以及被引用块的 ID。
+// 生成器不支持块属性时丢弃全部属性，被引用块的 ID 改为 HTML 锚点。
+func (r *FormatRenderer) translateIAL() {
+	var unlinks []*ast.Node
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeKramdownSpanIAL:
+			// Goldmark 不支持行级属性
+			unlinks = append(unlinks, n)
+		case ast.NodeKramdownBlockIAL:
+			block := n.Previous
+			if nil == block || util.IsDocIAL(n.Tokens) || ast.NodeListItem == block.Type {
+				unlinks = append(unlinks, n)
+				return ast.WalkContinue
+			}
+			if AttrsBlock != r.Syntax.Attrs {
+				// 标题的锚点由 renderHeading 输出
+				if id := n.IALAttr("id"); ast.NodeHeading != block.Type && r.refIDs[id] {
+					insertHTMLAnchor(block, id)
+				}
+				block.KramdownIAL = nil
+				unlinks = append(unlinks, n)
+				return ast.WalkContinue
+			}
+			// 标题的锚点由 headingAnchors 统一确定
+			attrs := r.goldmarkAttrs(parse.Tokens2IAL(n.Tokens), ast.NodeHeading != block.Type)
+			if attrs == "" {
+				block.KramdownIAL = nil
+				unlinks = append(unlinks, n)
+				return ast.WalkContinue
+			}
+			n.Tokens = []byte(attrs)
+		}
+		return ast.WalkContinue
+	})
+	for _, n := range unlinks {
+		n.Unlink()
+	}
+}
+
+// goldmarkAttrs 将属性列表转换为形如 {#id .class style="..."} 的 Goldmark 属性，没有需要保留的属性时返回空串
+func (r *FormatRenderer) goldmarkAttrs(ial [][]string, keepID bool) string {
+	var id, style string
+	var classes []string
+	for _, kv := range ial {
+		name, value := kv[0], html.UnescapeAttrVal(kv[1])
+		switch name {
+		case "id":
+			if keepID && r.refIDs[value] {
+				id = value
+			}
+		case "class", "custom-class":
+			classes = append(classes, strings.Fields(value)...)
+		case "style":
+			style = strings.ReplaceAll(value, "\"", "'")
+		}
+	}
+	var attrs []string
+	if id != "" {
+		attrs = append(attrs, "#"+id)
+	}
+	for _, c := range classes {
+		attrs = append(attrs, "."+c)
+	}
+	if style != "" {
+		attrs = append(attrs, "style=\""+style+"\"")
+	}
+	if len(attrs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(attrs, " ") + "}"
+}
+
+// insertHTMLAnchor 为块插入 HTML 锚点：块中有段落时在第一个段落开头插入空的 a 元素，否则在块之前插入空的 div 元素
+func insertHTMLAnchor(block *ast.Node, id string) {
+	var paragraph *ast.Node
+	ast.Walk(block, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeParagraph == n.Type {
+			paragraph = n
+			return ast.WalkStop
+		}
+		return ast.WalkContinue
+	})
+	if nil != paragraph {
+		paragraph.PrependChild(&ast.Node{Type: ast.NodeInlineHTML, Tokens: []byte(htmlAnchor("a", id))})
+		return
+	}
+	block.InsertBefore(&ast.Node{Type: ast.NodeHTMLBlock, Tokens: []byte(htmlAnchor("div", id))})
+}
+
+// htmlAnchor 返回带有 id 的空 HTML 元素
+func htmlAnchor(tag, id string) string {
+	return "<" + tag + " id=\"" + html.EscapeAttrVal(id) + "\"></" + tag + ">"
+}
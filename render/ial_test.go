@@ -0,0 +1,23 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestTranslateIAL(t *testing.T) {
+	md := "段落\n{: id=\"20220101000000-aaaaaaa\" style=\"color: red\" updated=\"20220101000000\"}\n\n> 引述\n{: class=\"note\"}\n"
+	tests := []struct {
+		syntax *Syntax
+		want   string
+	}{
+		// 默认配置下 Hugo 不解析块属性，只能丢弃
+		{SyntaxHugo, "段落\n\n> 引述\n"},
+		{SyntaxHugoBlock, "段落\n{style=\"color: red\"}\n\n> 引述\n{.note}\n"},
+		{SyntaxCommonMark, "段落\n\n> 引述\n"},
+	}
+	for _, tt := range tests {
+		if got := format(md, tt.syntax); got != tt.want {
+			t.Errorf("format() with %s = %q, want %q", tt.syntax.Name, got, tt.want)
+		}
+	}
+}
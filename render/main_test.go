@@ -0,0 +1,54 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syblog/service"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+)
+
+// TestMain 使用临时目录中的配置文件，并以返回空结果的服务代替思源笔记API
+func TestMain(m *testing.M) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"code":0,"msg":"","data":[]}`)
+	}))
+	dir, err := os.MkdirTemp("", "syblog")
+	if err != nil {
+		panic(err)
+	}
+	cfg := fmt.Sprintf("[siyuan]\napiURL = %q\nworkspacePath = %q\n\n[hugo]\nblogPath = %q\n",
+		strings.TrimPrefix(ts.URL, "http://"), dir, dir)
+	path := filepath.Join(dir, "config.toml")
+	if err = os.WriteFile(path, []byte(cfg), 0644); err != nil {
+		panic(err)
+	}
+	os.Setenv("SYBLOG_CONFIG", path)
+	code := m.Run()
+	ts.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// format 按导出时的解析和渲染选项格式化 Markdown
+func format(md string, syntax *Syntax) string {
+	luteEngine := lute.New()
+	luteEngine.ParseOptions.KramdownBlockIAL = true
+	luteEngine.ParseOptions.KramdownSpanIAL = true
+	luteEngine.ParseOptions.Mark = true
+	luteEngine.ParseOptions.Sup = true
+	luteEngine.ParseOptions.Sub = true
+	luteEngine.ParseOptions.ToC = true
+	tree := parse.Parse("", []byte(md), luteEngine.ParseOptions)
+	luteEngine.RenderOptions.KramdownBlockIAL = true
+	article := &service.Article{ID: "20220101000000-abcdefg"}
+	renderer := NewFormatRenderer(tree, luteEngine.RenderOptions, article, service.NewArticleList(), syntax)
+	return string(renderer.Render())
+}
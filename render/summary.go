@@ -0,0 +1,61 @@
+package render
+
+import (
+	"strings"
+	"syblog/config"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// markSummary 将带有 custom-summary 属性的块作为文章摘要，
+// 开启 summaryMore 时在摘要块之后插入 <!--more-->，没有摘要块时插入在纯文本达到 summaryLength 字的块之后。
+// 需要在 translateIAL 丢弃自定义属性之前调用。
+func (r *FormatRenderer) markSummary() {
+	var summary *ast.Node
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeKramdownBlockIAL != n.Type || nil == n.Previous || util.IsDocIAL(n.Tokens) {
+			return ast.WalkContinue
+		}
+		for _, kv := range parse.Tokens2IAL(n.Tokens) {
+			if kv[0] == "custom-summary" && kv[1] != "" {
+				summary = n.Previous
+				return ast.WalkStop
+			}
+		}
+		return ast.WalkContinue
+	})
+	if summary != nil {
+		r.article.Summary = strings.TrimSpace(PlainText(summary))
+	}
+	if !config.GetConfig().Hugo.SummaryMore {
+		return
+	}
+
+	var after *ast.Node
+	if summary != nil {
+		after = summary
+		for after.Parent != r.Tree.Root {
+			after = after.Parent
+		}
+	} else {
+		count := 0
+		for c := r.Tree.Root.FirstChild; c != nil; c = c.Next {
+			count += utf8.RuneCountInString(PlainText(c))
+			if count >= config.GetConfig().Hugo.SummaryLength {
+				after = c
+				break
+			}
+		}
+	}
+	// 文章没有超过摘要长度时不需要分隔
+	if nil == after || nil == after.Next {
+		return
+	}
+	if ast.NodeKramdownBlockIAL == after.Next.Type {
+		after = after.Next
+	}
+	after.InsertAfter(&ast.Node{Type: ast.NodeHTMLBlock, Tokens: []byte("<!--more-->")})
+}
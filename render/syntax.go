@@ -0,0 +1,77 @@
+package render
+
+import (
+	"strconv"
+)
+
+// 属性语法的支持程度
+const (
+	AttrsBlock   = "block"   // 支持 Goldmark 的块属性和标题属性，如：{#id .class style="..."}
+	AttrsHeading = "heading" // 只支持标题末尾的 {#id}
+	AttrsNone    = "none"    // 不支持属性，锚点输出为 HTML 元素
+)
+
+// Syntax 描述了目标站点生成器能够识别的短代码和属性语法，不支持的语法会回退为 HTML。
+type Syntax struct {
+	Name string
+	// Shortcode 按生成器的语法生成短代码，arg 为空时没有参数，为 nil 时不支持短代码
+	Shortcode func(name, arg string) string
+	// Shortcodes 为生成器内置的短代码，主题提供的短代码需要在配置中指定
+	Shortcodes map[string]bool
+	// Attrs 为支持的属性语法
+	Attrs string
+	// AutoAnchor 为 true 时生成器会按 github 风格自动生成标题锚点，与之相同的锚点不需要输出
+	AutoAnchor bool
+}
+
+// hugoShortcode 生成形如 {{< name arg >}} 的 Hugo 短代码
+func hugoShortcode(name, arg string) string {
+	if arg == "" {
+		return "{{< " + name + " >}}"
+	}
+	return "{{< " + name + " " + arg + " >}}"
+}
+
+var (
+	// SyntaxHugo 为 Hugo 默认配置下的 Goldmark 语法，只支持标题属性，块属性会被当作段落文本
+	SyntaxHugo = &Syntax{
+		Name:       "hugo",
+		Shortcode:  hugoShortcode,
+		Shortcodes: map[string]bool{"youtube": true},
+		Attrs:      AttrsHeading,
+		AutoAnchor: true,
+	}
+
+	// SyntaxHugoBlock 为开启了 markup.goldmark.parser.attribute.block 的 Hugo 语法，支持块属性
+	SyntaxHugoBlock = &Syntax{
+		Name:       "hugo",
+		Shortcode:  hugoShortcode,
+		Shortcodes: map[string]bool{"youtube": true},
+		Attrs:      AttrsBlock,
+		AutoAnchor: true,
+	}
+
+	// SyntaxZola 为 Zola 的 pulldown-cmark 语法，短代码形如 {{ name(id="arg") }}，没有内置短代码
+	SyntaxZola = &Syntax{
+		Name: "zola",
+		Shortcode: func(name, arg string) string {
+			if arg == "" {
+				return "{{ " + name + "() }}"
+			}
+			return "{{ " + name + "(id=" + strconv.Quote(arg) + ") }}"
+		},
+		Attrs: AttrsHeading,
+	}
+
+	// SyntaxKramdown 为 Jekyll 的 kramdown 语法，Liquid 模板不作为短代码使用
+	SyntaxKramdown = &Syntax{
+		Name:  "kramdown",
+		Attrs: AttrsHeading,
+	}
+
+	// SyntaxCommonMark 为只支持 CommonMark 和 HTML 的语法，如 Hexo 和 Astro
+	SyntaxCommonMark = &Syntax{
+		Name:  "commonmark",
+		Attrs: AttrsNone,
+	}
+)
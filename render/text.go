@@ -0,0 +1,104 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+var (
+	headingAttrRegexp = regexp.MustCompile(`(?m)^(#{1,6} .*?)[ \t]*\{[ \t]*(#[^\s{}"]+)?(?:[ \t]*(?:\.[^\s{}"]+|\w+="[^"]*"))*[ \t]*\}\r?$`)
+	blockAttrRegexp   = regexp.MustCompile(`(?m)^[ \t]*\{(?:[#.][^\s{}"]+|\w+="[^"]*")(?:[ \t]+(?:[#.][^\s{}"]+|\w+="[^"]*"))*\}\r?$`)
+	shortcodeRegexp   = regexp.MustCompile(`\{\{<\s*(\w+)\s*(.*?)\s*>\}\}|\{\{%\s*(\w+)\s*(.*?)\s*%\}\}|\{\{\s*(\w+)\((.*?)\)\s*\}\}`)
+	zolaArgRegexp     = regexp.MustCompile(`^\w+\s*=\s*"(.*)"$`)
+)
+
+// StripAttrs 去掉 Markdown 中的 Goldmark 属性，标题只保留 {#id}，lute 开启 HeadingID 后可以解析为标题 ID
+func StripAttrs(md string) string {
+	md = headingAttrRegexp.ReplaceAllStringFunc(md, func(s string) string {
+		m := headingAttrRegexp.FindStringSubmatch(s)
+		if m[2] == "" {
+			return m[1]
+		}
+		return m[1] + " {" + m[2] + "}"
+	})
+	return blockAttrRegexp.ReplaceAllString(md, "")
+}
+
+// ReplaceShortcodes 将 Hugo（{{< name arg >}}、{{% name arg %}}）和 Zola（{{ name(id="arg") }}）形式的短代码替换为 replace 的返回值
+func ReplaceShortcodes(md string, replace func(name, arg string) string) string {
+	return shortcodeRegexp.ReplaceAllStringFunc(md, func(s string) string {
+		m := shortcodeRegexp.FindStringSubmatch(s)
+		switch {
+		case m[1] != "":
+			return replace(m[1], m[2])
+		case m[3] != "":
+			return replace(m[3], m[4])
+		default:
+			arg := m[6]
+			if a := zolaArgRegexp.FindStringSubmatch(arg); nil != a {
+				arg = a[1]
+			}
+			return replace(m[5], arg)
+		}
+	})
+}
+
+// PlainText 返回节点渲染后的纯文本，与 ast.Node.Text 不同的是会保留行内代码和公式的内容
+func PlainText(node *ast.Node) string {
+	var b strings.Builder
+	ast.Walk(node, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeText, ast.NodeLinkText, ast.NodeCodeSpanContent, ast.NodeInlineMathContent,
+			ast.NodeBlockRefText, ast.NodeBlockRefDynamicText, ast.NodeFileAnnotationRefText, ast.NodeBackslashContent:
+			b.Write(n.Tokens)
+		case ast.NodeSoftBreak, ast.NodeHardBreak:
+			b.WriteByte(' ')
+		case ast.NodeHeadingID, ast.NodeKramdownSpanIAL, ast.NodeKramdownBlockIAL:
+			return ast.WalkSkipChildren
+		}
+		return ast.WalkContinue
+	})
+	return strings.ReplaceAll(b.String(), util.Caret, "")
+}
+
+// MarkdownText 返回渲染后文章的纯文本，每个块占一行。
+// 代码块保留代码内容，Goldmark 属性、Hugo 短代码和 HTML 标签会被去掉。
+func MarkdownText(md string) string {
+	md = StripAttrs(md)
+	md = ReplaceShortcodes(md, func(name, arg string) string { return "" })
+	luteEngine := lute.New()
+	luteEngine.ParseOptions.HeadingID = true
+	luteEngine.ParseOptions.Mark = true
+	luteEngine.ParseOptions.Sup = true
+	luteEngine.ParseOptions.Sub = true
+	tree := parse.Parse("", []byte(md), luteEngine.ParseOptions)
+	var b strings.Builder
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		var text string
+		switch n.Type {
+		case ast.NodeParagraph, ast.NodeHeading, ast.NodeTableCell:
+			text = PlainText(n)
+		case ast.NodeCodeBlockCode, ast.NodeMathBlockContent:
+			text = util.BytesToStr(n.Tokens)
+		default:
+			return ast.WalkContinue
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			b.WriteString(text)
+			b.WriteByte('\n')
+		}
+		return ast.WalkSkipChildren
+	})
+	return b.String()
+}
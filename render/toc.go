@@ -0,0 +1,75 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"syblog/config"
+
+	"github.com/88250/lute/ast"
+)
+
+// 目录的输出方式
+const (
+	ToCFrontMatter = "frontmatter" // 在 Front Matter 中设置 toc = true，由主题渲染目录
+	ToCShortcode   = "shortcode"   // 输出主题提供的 toc 短代码，生成器不支持短代码时与 static 相同
+	ToCStatic      = "static"      // 输出由标题锚点组成的静态嵌套列表
+	ToCNone        = "none"        // 直接移除
+)
+
+func (r *FormatRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+	switch config.GetConfig().Hugo.ToC {
+	case ToCShortcode:
+		if nil != r.Syntax.Shortcode {
+			r.WriteString(r.Syntax.Shortcode("toc", "") + "\n\n")
+		} else {
+			r.Write(r.staticToC())
+		}
+	case ToCStatic:
+		r.Write(r.staticToC())
+	case ToCNone:
+	default:
+		r.article.ToC = true
+	}
+	return ast.WalkContinue
+}
+
+// staticToC 生成由标题链接组成的嵌套列表
+func (r *FormatRenderer) staticToC() []byte {
+	var headings []*ast.Node
+	minLevel := 6
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		if ast.NodeFootnotesDefBlock == n.Type {
+			return ast.WalkSkipChildren
+		}
+		if ast.NodeHeading == n.Type {
+			headings = append(headings, n)
+			if n.HeadingLevel < minLevel {
+				minLevel = n.HeadingLevel
+			}
+			return ast.WalkSkipChildren
+		}
+		return ast.WalkContinue
+	})
+	if len(headings) == 0 {
+		return nil
+	}
+
+	anchors := r.headingAnchors()
+	buf := bytes.Buffer{}
+	for _, h := range headings {
+		buf.WriteString(strings.Repeat("  ", h.HeadingLevel-minLevel))
+		buf.WriteString("- [")
+		buf.WriteString(strings.TrimSpace(PlainText(h)))
+		buf.WriteString("](#")
+		buf.WriteString(anchors[h])
+		buf.WriteString(")\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"syblog/config"
+	"syblog/logger"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// 本地挂件的替换方式
+const (
+	WidgetPlaceholder = "placeholder" // 替换为仅带挂件名称的占位元素
+	WidgetData        = "data"        // 替换为带有挂件 custom-* 属性快照的占位元素
+)
+
+var (
+	srcAttrRegexp  = regexp.MustCompile(`\ssrc="([^"]*)"`)
+	bilibiliRegexp = regexp.MustCompile(`player\.bilibili\.com/player\.html\?.*\bbvid=(BV\w+)`)
+	youtubeRegexp  = regexp.MustCompile(`youtube(?:-nocookie)?\.com/embed/([\w-]+)`)
+)
+
+// convertEmbeds 处理文档中的挂件和 iframe：
+// 哔哩哔哩视频改为 https 的播放器地址，配置了 bilibiliShortcode 且生成器支持短代码时替换为主题提供的短代码，
+// YouTube 视频在生成器内置 youtube 短代码时替换为短代码，否则改为播放器地址，
+// 本地挂件替换为占位元素，指向本机地址的 iframe 会输出警告。
+func (r *FormatRenderer) convertEmbeds() {
+	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		if ast.NodeHTMLBlock == n.Type {
+			// 非所见即所得模式下 iframe 会被解析为 HTML 块
+			tokens := bytes.TrimSpace(n.Tokens)
+			if !bytes.HasPrefix(tokens, []byte("<iframe")) || !bytes.HasSuffix(tokens, []byte(">")) {
+				return ast.WalkContinue
+			}
+			n.Tokens = tokens
+			if bytes.Contains(tokens, []byte("data-subtype=\"widget\"")) {
+				n.Type = ast.NodeWidget
+			} else {
+				n.Type = ast.NodeIFrame
+			}
+		}
+		if ast.NodeWidget != n.Type && ast.NodeIFrame != n.Type {
+			return ast.WalkContinue
+		}
+
+		var src string
+		if m := srcAttrRegexp.FindSubmatch(n.Tokens); nil != m {
+			src = html.UnescapeAttrVal(util.BytesToStr(m[1]))
+		}
+		if m := bilibiliRegexp.FindStringSubmatch(src); nil != m {
+			if name := config.GetConfig().Hugo.BilibiliShortcode; name != "" && nil != r.Syntax.Shortcode {
+				n.Tokens = []byte(r.Syntax.Shortcode(name, m[1]))
+			} else {
+				n.Tokens = bilibiliIFrame(src)
+			}
+		} else if m := youtubeRegexp.FindStringSubmatch(src); nil != m {
+			if r.Syntax.Shortcodes["youtube"] {
+				n.Tokens = []byte(r.Syntax.Shortcode("youtube", m[1]))
+			} else {
+				n.Tokens = youtubeIFrame(m[1])
+			}
+		} else if ast.NodeWidget == n.Type || strings.HasPrefix(src, "/widgets/") {
+			n.Tokens = widgetPlaceholder(src, n.KramdownIAL)
+		} else if u, err := url.Parse(src); err == nil && (u.Hostname() == "127.0.0.1" || u.Hostname() == "localhost") {
+			logger.Warnf("文章《%s》中的iframe指向本机地址：%s", r.article.Title, src)
+		}
+		return ast.WalkContinue
+	})
+}
+
+// bilibiliIFrame 生成哔哩哔哩播放器的 iframe，思源笔记中的地址可能省略协议，统一改为 https
+func bilibiliIFrame(src string) []byte {
+	src = "https://" + strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(src, "https:"), "http:"), "//")
+	return []byte("<iframe src=\"" + html.EscapeAttrVal(src) + "\" scrolling=\"no\" frameborder=\"no\" allowfullscreen=\"true\" style=\"width: 100%; aspect-ratio: 16 / 9;\"></iframe>")
+}
+
+// youtubeIFrame 生成 YouTube 播放器的 iframe
+func youtubeIFrame(id string) []byte {
+	return []byte("<iframe src=\"https://www.youtube-nocookie.com/embed/" + html.EscapeAttrVal(id) + "\" frameborder=\"0\" allowfullscreen style=\"width: 100%; aspect-ratio: 16 / 9;\"></iframe>")
+}
+
+// widgetPlaceholder 生成本地挂件的占位元素
+func widgetPlaceholder(src string, ial [][]string) []byte {
+	name := strings.Split(strings.TrimPrefix(src, "/widgets/"), "/")[0]
+	buf := bytes.Buffer{}
+	buf.WriteString("<div class=\"sy-widget\" data-widget=\"" + html.EscapeAttrVal(name) + "\"")
+	if config.GetConfig().Hugo.Widget == WidgetData {
+		attrs := parse.IAL2Map(ial)
+		names := make([]string, 0, len(attrs))
+		for k := range attrs {
+			if strings.HasPrefix(k, "custom-") {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			buf.WriteString(" data-" + k + "=\"" + html.EscapeAttrVal(attrs[k]) + "\"")
+		}
+	}
+	buf.WriteString("></div>")
+	return buf.Bytes()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syblog/config"
+	"syblog/exporter"
+	"syblog/logger"
+	"syblog/render"
+	"syblog/service"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// searchDoc 描述了搜索索引中的一篇文章，可直接作为 Fuse.js 或 Lunr 的文档
+type searchDoc struct {
+	Title   string   `json:"title"`
+	URL     string   `json:"url"`
+	Tags    []string `json:"tags"`
+	Date    string   `json:"date"`
+	Content string   `json:"content"`
+}
+
+// invertedDoc 描述了倒排索引中的一篇文章
+type invertedDoc struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// invertedIndex 为预先生成的倒排索引，index 的键为词，值为 [文章序号, 词频] 的列表
+type invertedIndex struct {
+	Docs  []invertedDoc       `json:"docs"`
+	Index map[string][][2]int `json:"index"`
+}
+
+// writeSearchIndex 将已发布文章渲染后的纯文本写入静态文件目录的 search/index.json，
+// 开启 searchInverted 时还会写入 search/inverted.json。
+// 索引使用渲染后的内容生成，没有输出到文章中的内容也不会出现在索引中。
+func writeSearchIndex(exp exporter.Exporter, articles *service.ArticleList) {
+	if !config.GetConfig().Hugo.Search {
+		return
+	}
+	docs := make([]*searchDoc, 0, articles.Len())
+	for e := articles.Front(); e != nil; e = e.Next() {
+		article := e.Value.(*service.Article)
+		tags := article.Tags
+		if tags == nil {
+			tags = make([]string, 0)
+		}
+		docs = append(docs, &searchDoc{
+			Title:   article.Title,
+			URL:     service.ArticleLink(article),
+			Tags:    tags,
+			Date:    article.Created.Format("2006-01-02"),
+			Content: render.MarkdownText(article.Content),
+		})
+	}
+
+	dir := filepath.Join(exp.StaticPath(), "search")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	writeJSON(filepath.Join(dir, "index.json"), docs)
+	if config.GetConfig().Hugo.SearchInverted {
+		writeJSON(filepath.Join(dir, "inverted.json"), buildInvertedIndex(docs))
+	}
+}
+
+// buildInvertedIndex 对标题、标签和正文分词后生成倒排索引
+func buildInvertedIndex(docs []*searchDoc) *invertedIndex {
+	ret := &invertedIndex{Docs: make([]invertedDoc, 0, len(docs)), Index: make(map[string][][2]int)}
+	for i, doc := range docs {
+		ret.Docs = append(ret.Docs, invertedDoc{Title: doc.Title, URL: doc.URL})
+		tf := make(map[string]int)
+		var terms []string
+		for _, text := range append([]string{doc.Title, doc.Content}, doc.Tags...) {
+			for _, term := range tokenize(text) {
+				if tf[term] == 0 {
+					terms = append(terms, term)
+				}
+				tf[term]++
+			}
+		}
+		for _, term := range terms {
+			ret.Index[term] = append(ret.Index[term], [2]int{i, tf[term]})
+		}
+	}
+	return ret
+}
+
+// tokenize 对文本分词：字母和数字按单词切分并转为小写，中日韩文字按相邻两字切分，单独的一个字作为一个词。
+// 搜索时对查询词使用相同的规则分词即可匹配。
+func tokenize(text string) []string {
+	var ret []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			ret = append(ret, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			ret = append(ret, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			ret = append(ret, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return ret
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// writeJSON 将数据以 JSON 格式写入文件
+func writeJSON(path string, data any) {
+	bs, err := json.Marshal(data)
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	if err = os.WriteFile(path, bs, 0644); err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World", []string{"hello", "world"}},
+		{"Go1.19", []string{"go1", "19"}},
+		{"思源笔记", []string{"思源", "源笔", "笔记"}},
+		{"用Go写博客", []string{"用", "go", "写博", "博客"}},
+		{"ひらがな", []string{"ひら", "らが", "がな"}},
+		{"  ，。 ", nil},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"syblog/config"
+	"syblog/service"
+)
+
+// articleSeries 描述了文章在系列中的位置，系列索引页的 Pages 为系列中按顺序排列的文章
+type articleSeries struct {
+	Name  string
+	Order int
+	Prev  *service.Link
+	Next  *service.Link
+	Pages []service.Link
+}
+
+// findSeries 将设置了 custom-series=1 属性的文档作为系列，已发布的子文档按文档树中的排序成为系列中的文章，
+// 上级文档已发布时成为系列的索引页
+func findSeries(articles *service.ArticleList) map[string]*articleSeries {
+	ret := make(map[string]*articleSeries)
+	if !config.GetConfig().Hugo.Series {
+		return ret
+	}
+
+	children := make(map[string][]*service.Article)
+	var parentIDs []string
+	for e := articles.Front(); e != nil; e = e.Next() {
+		article := e.Value.(*service.Article)
+		ancestors := article.Ancestors()
+		if len(ancestors) == 0 {
+			continue
+		}
+		pid := service.PathID(ancestors[len(ancestors)-1])
+		if children[pid] == nil {
+			parentIDs = append(parentIDs, pid)
+		}
+		children[pid] = append(children[pid], article)
+	}
+
+	get := func(id string) *articleSeries {
+		if ret[id] == nil {
+			ret[id] = &articleSeries{}
+		}
+		return ret[id]
+	}
+	for id := range service.FindSeriesIDs(parentIDs) {
+		parts := children[id]
+		sort.SliceStable(parts, func(i, j int) bool {
+			return service.FindDocWeight(parts[i].Box, parts[i].Path) < service.FindDocWeight(parts[j].Box, parts[j].Path)
+		})
+		name := seriesName(id, parts[0], articles)
+		pages := make([]service.Link, 0, len(parts))
+		for _, a := range parts {
+			pages = append(pages, service.Link{Title: a.Title, URL: service.ArticleLink(a)})
+		}
+		for i, a := range parts {
+			s := get(a.ID)
+			s.Name = name
+			s.Order = i + 1
+			if i > 0 {
+				s.Prev = &pages[i-1]
+			}
+			if i < len(parts)-1 {
+				s.Next = &pages[i+1]
+			}
+		}
+		if articles.Exist(id) {
+			get(id).Pages = pages
+		}
+	}
+	return ret
+}
+
+// seriesName 返回系列的名称，即上级文档的标题，上级文档没有发布时从子文档的文档路径中获取
+func seriesName(id string, child *service.Article, articles *service.ArticleList) string {
+	if a := articles.Get(id); a != nil {
+		return a.Title
+	}
+	titles := strings.Split(strings.TrimPrefix(child.HPath, "/"), "/")
+	if len(titles) < 2 {
+		return child.Title
+	}
+	return titles[len(titles)-2]
+}
+
+// setSeriesFrontMatter 将系列输出到 Front Matter：系列中的文章输出 series、series_order、prev 和 next，索引页输出 series_pages
+func setSeriesFrontMatter(series *articleSeries, fmMap map[string]any) {
+	if series == nil {
+		return
+	}
+	if series.Name != "" {
+		fmMap["series"] = series.Name
+		fmMap["series_order"] = series.Order
+		if series.Prev != nil {
+			fmMap["prev"] = linkMaps([]service.Link{*series.Prev})[0]
+		}
+		if series.Next != nil {
+			fmMap["next"] = linkMaps([]service.Link{*series.Next})[0]
+		}
+	}
+	if len(series.Pages) > 0 {
+		fmMap["series_pages"] = linkMaps(series.Pages)
+	}
+}
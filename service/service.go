@@ -2,8 +2,16 @@ package service
 
 import (
 	"container/list"
+	"encoding/json"
 	"fmt"
+	"html"
 	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"syblog/config"
 	"syblog/logger"
@@ -24,8 +32,63 @@ type Article struct {
 	Linked  []string
 	Asserts []string
 	ID      string
+	ToC     bool
+	Emojis  []string
+	Box     string // 所在笔记本的ID
+	Path    string // 由文档ID组成的路径，如：/20200812220555-lj3enxa/20210808180320-fqgskfj.sy
+	HPath   string // 由文档标题组成的路径，如：/技术/Go
+	Section string // 文章所在的 section
+	Summary string // 带有 custom-summary 属性的块的纯文本，没有时为空
+
+	Draft       bool      // 设置了 custom-publish=draft 的草稿
+	PublishDate time.Time // custom-publish-date 属性设置的发布时间，在此之前不发布
+	ExpiryDate  time.Time // custom-expiry-date 属性设置的过期时间，在此之后不再发布
+}
+
+// Link 描述了指向一篇文章的链接
+type Link struct {
+	Title    string        `json:"title" toml:"title" yaml:"title"`
+	URL      string        `json:"url" toml:"url" yaml:"url"`
+	Contexts []LinkContext `json:"contexts,omitempty" toml:"contexts,omitempty" yaml:"contexts,omitempty"`
+}
+
+// LinkContext 描述了反链中引用所在的块
+type LinkContext struct {
+	Excerpt string `json:"excerpt" toml:"excerpt" yaml:"excerpt"` // 引用所在块的摘录，HTML 格式，锚文本使用 <mark> 标记
+	URL     string `json:"url" toml:"url" yaml:"url"`             // 指向引用所在块的链接
+}
+
+// Block 描述了思源笔记中的内容块
+type Block struct {
+	ID      string
+	Type    string
+	SubType string
+	Content string
 }
 
+// Annotation 描述了 PDF 文件中的标注，数据来自工作空间中与 PDF 同名的 .sya 文件
+type Annotation struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Index   *int   `json:"index"`
+	Pages   []struct {
+		Index int `json:"index"`
+	} `json:"pages"`
+}
+
+// Page 返回标注所在的页码，从1开始
+func (a *Annotation) Page() int {
+	if a.Index != nil {
+		return *a.Index + 1
+	}
+	if len(a.Pages) > 0 {
+		return a.Pages[0].Index + 1
+	}
+	return 1
+}
+
+var annotations = make(map[string]map[string]*Annotation)
+
 type ArticleList struct {
 	ls    *list.List
 	index map[string]*list.Element
@@ -67,8 +130,41 @@ func (al *ArticleList) Len() int {
 	return al.ls.Len()
 }
 
+// Sections 返回所有文章所在的 section 以及配置中的 section，不重复
+func (al *ArticleList) Sections() []string {
+	ret := []string{config.GetConfig().Hugo.SectionName}
+	exist := map[string]bool{config.GetConfig().Hugo.SectionName: true}
+	add := func(s string) {
+		if !exist[s] {
+			exist[s] = true
+			ret = append(ret, s)
+		}
+	}
+	for _, s := range config.GetConfig().Hugo.Sections {
+		add(s)
+	}
+	for e := al.Front(); e != nil; e = e.Next() {
+		add(e.Value.(*Article).Section)
+	}
+	return ret
+}
+
+// FindArticleList 查询需要发布的文档：设置了 custom-publish=1（草稿为 custom-publish=draft）或满足任一 include 选择器，
+// 且不满足任何 exclude 选择器。当前不能发布的草稿、定时发布和已过期的文档会被跳过。
 func FindArticleList() *ArticleList {
-	l, err := findList("select * from blocks where type='d' and id in (select block_id from attributes where name='custom-publish' and value='1')")
+	include := []string{"id in (select block_id from attributes where name='custom-publish' and value in ('1','draft'))"}
+	for _, s := range config.GetConfig().Publish.Include {
+		if cond := selectorCond(s); cond != "" {
+			include = append(include, cond)
+		}
+	}
+	stmt := "select * from blocks where type='d' and (" + strings.Join(include, " or ") + ")"
+	for _, s := range config.GetConfig().Publish.Exclude {
+		if cond := selectorCond(s); cond != "" {
+			stmt += " and not " + cond
+		}
+	}
+	l, err := findList(stmt + " limit " + strconv.Itoa(maxArticles))
 	if err != nil {
 		logger.Fatalf("%+v", errors.WithStack(err))
 	}
@@ -92,11 +188,145 @@ func FindArticleList() *ArticleList {
 		}
 		tagStr := doc["tag"].(string)
 		article.Tags = parseTag(tagStr)
+		article.Box = doc["box"].(string)
+		article.Path = doc["path"].(string)
+		article.HPath = doc["hpath"].(string)
+		article.Section = findSection(article)
+		findPublishState(article)
+		if reason := article.HoldReason(); reason != "" {
+			logger.Infof("暂不发布《%s》：%s", article.Title, reason)
+			continue
+		}
 		as.Put(article)
 	}
 	return as
 }
 
+// IsExcluded 返回文档是否满足任一 exclude 选择器，用于检查被已发布文章链接的文档
+func IsExcluded(id string) bool {
+	var conds []string
+	for _, s := range config.GetConfig().Publish.Exclude {
+		if cond := selectorCond(s); cond != "" {
+			conds = append(conds, cond)
+		}
+	}
+	if len(conds) == 0 {
+		return false
+	}
+	l, err := findList("select id from blocks where id='" + id + "' and (" + strings.Join(conds, " or ") + ")")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	return len(l) > 0
+}
+
+// maxArticles 为查询文档时的数量上限，避免思源笔记默认的 SQL 查询条数限制
+const maxArticles = 100000
+
+// selectorCond 将选择器转换为 SQL 条件，选择器中的各项需要同时满足，没有设置任何项时返回空串
+func selectorCond(s config.Selector) string {
+	var conds []string
+	if s.Box != "" {
+		conds = append(conds, "box='"+sqlEscape(findNotebookID(s.Box))+"'")
+	}
+	if s.HPath != "" {
+		hpath := "/" + strings.Trim(s.HPath, "/")
+		conds = append(conds, "(hpath='"+sqlEscape(hpath)+"' or hpath like '"+sqlEscape(hpath)+"/%')")
+	}
+	if s.Tag != "" {
+		conds = append(conds, "tag like '%#"+sqlEscape(s.Tag)+"#%'")
+	}
+	if s.SQL != "" {
+		conds = append(conds, "("+s.SQL+")")
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(conds, " and ") + ")"
+}
+
+func sqlEscape(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
+
+var notebookIDRegexp = regexp.MustCompile(`^\d{14}-[0-9a-z]{7}$`)
+
+// notebooks 为笔记本名称到ID的映射
+var notebooks map[string]string
+
+// findNotebookID 返回笔记本的ID，name 为笔记本ID时直接返回，否则按笔记本名称查找
+func findNotebookID(name string) string {
+	if notebookIDRegexp.MatchString(name) {
+		return name
+	}
+	if notebooks == nil {
+		result := &struct {
+			Result
+			Data struct {
+				Notebooks []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"notebooks"`
+			} `json:"data"`
+		}{}
+		_, err := client.R().SetBody(map[string]interface{}{}).SetResult(result).Post("http://" + config.GetConfig().SY.APIURL + "/api/notebook/lsNotebooks")
+		if err != nil {
+			logger.Fatalf("%+v", errors.WithStack(err))
+		}
+		notebooks = make(map[string]string)
+		for _, nb := range result.Data.Notebooks {
+			notebooks[nb.Name] = nb.ID
+		}
+	}
+	if id, ok := notebooks[name]; ok {
+		return id
+	}
+	logger.Errorf("未找到笔记本：%s", name)
+	return name
+}
+
+// boxSections 为笔记本ID到 section 的映射
+var boxSections map[string]string
+
+// findSection 确定文章所在的 section：优先使用 custom-sn-section 属性，其次使用笔记本对应的 section，默认为 sectionName
+func findSection(a *Article) string {
+	l, err := findList("select value from attributes where block_id='" + a.ID + "' and name='custom-sn-section'")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	if len(l) > 0 {
+		s := strings.TrimSuffix(strings.TrimSpace(l[0]["value"].(string)), "/")
+		if config.ValidSection(s) {
+			return s
+		}
+		if s != "" {
+			logger.Errorf("文章《%s》的section属性不合法：%s", a.Title, s)
+		}
+	}
+	if boxSections == nil {
+		boxSections = make(map[string]string)
+		for box, s := range config.GetConfig().Hugo.Sections {
+			boxSections[findNotebookID(box)] = s
+		}
+	}
+	if s, ok := boxSections[a.Box]; ok {
+		return s
+	}
+	return config.GetConfig().Hugo.SectionName
+}
+
+// FindBlockType 查询块的类型，如 d、h、p，未找到时返回空串
+func FindBlockType(id string) string {
+	l, err := findList("select type from blocks where id='" + id + "'")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	if len(l) == 0 {
+		return ""
+	}
+	return l[0]["type"].(string)
+}
+
 func FindArticleByBlockID(blockID string) *Article {
 	l, err := findList(fmt.Sprintf("select * from blocks where type='d' and id = (select root_id from blocks where id='%s')", blockID))
 	if err != nil {
@@ -126,9 +356,135 @@ func FindArticleByBlockID(blockID string) *Article {
 	}
 	tagStr := doc["tag"].(string)
 	article.Tags = parseTag(tagStr)
+	article.Box = doc["box"].(string)
+	article.Path = doc["path"].(string)
+	article.HPath = doc["hpath"].(string)
+	article.Section = findSection(article)
+	findPublishState(article)
 	return article
 }
 
+// findPublishState 读取文章的发布状态：custom-publish=draft 为草稿，custom-publish-date 和 custom-expiry-date 为发布和过期时间
+func findPublishState(a *Article) {
+	l, err := findList("select name,value from attributes where block_id='" + a.ID + "' and name in ('custom-publish','custom-publish-date','custom-expiry-date')")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	for _, attr := range l {
+		name, value := attr["name"].(string), attr["value"].(string)
+		if name == "custom-publish" {
+			a.Draft = strings.TrimSpace(value) == "draft"
+			continue
+		}
+		d, err := convertAttr(value, config.AttrConfig{Type: "date"})
+		if err != nil {
+			logger.Errorf("%+v", errors.Wrapf(err, "文章《%s》的属性%s转换失败", a.Title, name))
+			continue
+		}
+		if name == "custom-publish-date" {
+			a.PublishDate = d.(time.Time)
+		} else {
+			a.ExpiryDate = d.(time.Time)
+		}
+	}
+}
+
+// HoldReason 返回文章当前不能发布的原因，可以发布时返回空串：草稿只在预览时发布，未到发布时间或已过期的文章不发布
+func (a *Article) HoldReason() string {
+	now := time.Now()
+	if a.Draft && !config.GetConfig().Hugo.Preview {
+		return "草稿"
+	}
+	if !a.PublishDate.IsZero() && a.PublishDate.After(now) {
+		return "将于" + a.PublishDate.Format("2006-01-02 15:04") + "发布"
+	}
+	if !a.ExpiryDate.IsZero() && !a.ExpiryDate.After(now) {
+		return "已于" + a.ExpiryDate.Format("2006-01-02 15:04") + "过期"
+	}
+	return ""
+}
+
+// ArticleDirs 返回文章在 section 中的各级目录名：按文档树输出时为各级上级文档和文档自身的标题，否则只有文档标题
+func ArticleDirs(a *Article) []string {
+	if !config.GetConfig().Hugo.Hierarchy {
+		return []string{a.Title}
+	}
+	titles := strings.Split(strings.TrimPrefix(a.HPath, "/"), "/")
+	if len(titles) != len(a.Ancestors())+1 {
+		return []string{a.Title}
+	}
+	titles[len(titles)-1] = a.Title
+	return titles
+}
+
+// Ancestors 返回文章各级上级文档的路径，如：/20200812220555-lj3enxa.sy
+func (a *Article) Ancestors() []string {
+	ids := strings.Split(strings.TrimSuffix(strings.TrimPrefix(a.Path, "/"), ".sy"), "/")
+	ret := make([]string, 0, len(ids))
+	for i := 0; i < len(ids)-1; i++ {
+		ret = append(ret, "/"+strings.Join(ids[:i+1], "/")+".sy")
+	}
+	return ret
+}
+
+// PathID 返回文档路径对应的文档ID
+func PathID(p string) string {
+	return strings.TrimSuffix(path.Base(p), ".sy")
+}
+
+// ArticleSlug 返回文章在 section 中的路径：各级目录名转为小写并将空格替换为 -，以 / 分隔
+func ArticleSlug(a *Article) string {
+	dirs := ArticleDirs(a)
+	for i, d := range dirs {
+		dirs[i] = strings.ToLower(strings.ReplaceAll(d, " ", "-"))
+	}
+	return strings.Join(dirs, "/")
+}
+
+// ArticleLink 返回文章在站点中的访问地址
+func ArticleLink(a *Article) string {
+	dirs := strings.Split(ArticleSlug(a), "/")
+	for i, d := range dirs {
+		dirs[i] = url.QueryEscape(d)
+	}
+	return "/" + a.Section + "/" + strings.Join(dirs, "/") + "/"
+}
+
+var docWeights = make(map[string]map[string]int)
+
+// FindDocWeight 返回文档在文档树中的排序，从1开始，未找到时返回0
+func FindDocWeight(box, p string) int {
+	parent := path.Dir(p)
+	if parent != "/" {
+		parent += ".sy"
+	}
+	key := box + parent
+	weights, ok := docWeights[key]
+	if !ok {
+		result := &struct {
+			Result
+			Data struct {
+				Files []struct {
+					ID string `json:"id"`
+				} `json:"files"`
+			} `json:"data"`
+		}{}
+		_, err := client.R().SetBody(map[string]interface{}{
+			"notebook": box,
+			"path":     parent,
+		}).SetResult(result).Post("http://" + config.GetConfig().SY.APIURL + "/api/filetree/listDocsByPath")
+		if err != nil {
+			logger.Errorf("%+v", errors.WithStack(err))
+		}
+		weights = make(map[string]int)
+		for i, f := range result.Data.Files {
+			weights[f.ID] = i + 1
+		}
+		docWeights[key] = weights
+	}
+	return weights[PathID(p)]
+}
+
 func parseTag(str string) []string {
 	if str == "" {
 		return []string{}
@@ -160,6 +516,7 @@ func ExportMD(id string) (string, error) {
 	return result.Data["content"], nil
 }
 
+// FindAttrs 查询文档的 custom-sn-* 属性，按照配置的属性类型进行转换，返回输出键到值的映射
 func FindAttrs(id string) map[string]any {
 	attrs, err := findList("select name,value from attributes where root_id='" + id + "' and name like 'custom-sn-%'")
 	if err != nil {
@@ -169,52 +526,408 @@ func FindAttrs(id string) map[string]any {
 	for _, attr := range attrs {
 		key := attr["name"].(string)
 		key = strings.TrimPrefix(key, "custom-sn-")
+		if key == "section" {
+			// section 用于确定文章的输出位置，不输出到 Front Matter
+			continue
+		}
 		value := attr["value"].(string)
-		if key == "date" || key == "lastmod" {
-			d, err := time.Parse("2006-01-02T15:04:05", value)
-			if err != nil {
-				logger.Errorf("%+v", errors.WithStack(err))
-				continue
+		ac := config.GetConfig().Attrs[key]
+		v, err := convertAttr(value, ac)
+		if err != nil {
+			logger.Errorf("%+v", errors.Wrapf(err, "属性%s转换失败，文档ID为：%s", key, id))
+			continue
+		}
+		if ac.Key != "" {
+			key = ac.Key
+		}
+		ret[key] = v
+	}
+	return ret
+}
+
+var dateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02", time.RFC3339}
+
+func convertAttr(value string, ac config.AttrConfig) (any, error) {
+	value = strings.TrimSpace(value)
+	switch ac.Type {
+	case "bool":
+		return strconv.ParseBool(value)
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "list":
+		sep := ac.Separator
+		if sep == "" {
+			sep = ","
+		}
+		ret := make([]string, 0)
+		for _, item := range strings.Split(value, sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				ret = append(ret, item)
+			}
+		}
+		return ret, nil
+	case "date":
+		layouts := dateLayouts
+		if ac.Layout != "" {
+			layouts = []string{ac.Layout}
+		}
+		var err error
+		for _, layout := range layouts {
+			var d time.Time
+			if d, err = time.ParseInLocation(layout, value, time.Local); err == nil {
+				return d, nil
 			}
-			ret[key] = d
-		} else {
-			ret[key] = value
 		}
+		return nil, errors.WithStack(err)
+	default:
+		return value, nil
+	}
+}
+
+// FindDocAttrs 查询文档块自身的属性，如图标 icon、题头图 title-img
+func FindDocAttrs(id string) map[string]string {
+	attrs, err := findList("select name,value from attributes where block_id='" + id + "' and name not like 'custom-%'")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	ret := make(map[string]string)
+	for _, attr := range attrs {
+		ret[attr["name"].(string)] = attr["value"].(string)
 	}
 	return ret
 }
 
-func FindLinkTo(id string, articles *ArticleList) [][2]string {
-	ids, err := findList("select root_id from refs where def_block_root_id='" + id + "'")
+// FindLinkTo 查询引用了文档的其他已发布文章，即文档的反链
+// 开启 backlinksContext 时，每个反链还包含引用所在块的摘录和指向该块的链接。
+func FindLinkTo(id string, articles *ArticleList) []Link {
+	refs, err := findList("select root_id,block_id,content from refs where def_block_root_id='" + id + "' limit " + strconv.Itoa(maxArticles))
 	if err != nil {
 		logger.Fatalf("%+v", errors.WithStack(err))
 	}
-	ret := make([][2]string, 0)
-	for _, d := range ids {
-		aid := d["root_id"].(string)
-		if !articles.Exist(aid) {
+	var contents map[string]*Block
+	if config.GetConfig().Hugo.BacklinksContext {
+		contents = findBlockContents(refs)
+	}
+	ret := make([]Link, 0)
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		aid := ref["root_id"].(string)
+		a := articles.Get(aid)
+		if a == nil || aid == id {
 			continue
 		}
-		ls, err := findList("select content from blocks where id='" + aid + "'")
-		if err != nil {
-			logger.Errorf("%+v", errors.WithStack(err))
+		i, ok := index[aid]
+		if !ok {
+			i = len(ret)
+			index[aid] = i
+			ret = append(ret, Link{Title: a.Title, URL: ArticleLink(a)})
+		}
+		blockID := ref["block_id"].(string)
+		b, ok := contents[blockID]
+		if !ok || seen[blockID] {
 			continue
 		}
-		if len(ls) == 0 {
-			logger.Errorf("没有找到对应的文档块，id为：%s", aid)
+		seen[blockID] = true
+		ret[i].Contexts = append(ret[i].Contexts, LinkContext{
+			Excerpt: excerpt(b.Content, ref["content"].(string)),
+			URL:     ret[i].URL + "#" + BlockAnchor(blockID, b.Type == "h"),
+		})
+	}
+	return ret
+}
+
+// FindMentions 查询其他已发布文章中提及文章标题但没有引用文章的段落和标题块，即文章的提及。
+// 标题短于 mentionsMinLength 个字时不查询，避免常见词造成的干扰。
+func FindMentions(a *Article, articles *ArticleList) []Link {
+	ret := make([]Link, 0)
+	if len([]rune(a.Title)) < config.GetConfig().Hugo.MentionsMinLength {
+		return ret
+	}
+	like := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(a.Title)
+	l, err := findList("select id,root_id,content from blocks where type in ('p','h') and root_id!='" + a.ID + "'" +
+		" and content like '%" + sqlEscape(like) + "%' escape '\\'" +
+		" and id not in (select block_id from refs where def_block_root_id='" + a.ID + "')" +
+		" limit " + strconv.Itoa(maxArticles))
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	index := make(map[string]int)
+	for _, b := range l {
+		aid := b["root_id"].(string)
+		from := articles.Get(aid)
+		if from == nil {
 			continue
 		}
-		title := ls[0]["content"].(string)
-		item := [2]string{}
-		item[0] = title
-		t := strings.ReplaceAll(title, " ", "-")
-		link := "/" + config.GetConfig().Hugo.SectionName + "/" + url.QueryEscape(strings.ToLower(t)) + "/"
-		item[1] = link
-		ret = append(ret, item)
+		i, ok := index[aid]
+		if !ok {
+			i = len(ret)
+			index[aid] = i
+			ret = append(ret, Link{Title: from.Title, URL: ArticleLink(from)})
+		}
+		ret[i].Contexts = append(ret[i].Contexts, LinkContext{
+			Excerpt: excerpt(b["content"].(string), a.Title),
+			URL:     ret[i].URL,
+		})
+	}
+	return ret
+}
+
+// findBlockContents 查询引用所在块的类型和文本内容
+func findBlockContents(refs []map[string]any) map[string]*Block {
+	ret := make(map[string]*Block)
+	if len(refs) == 0 {
+		return ret
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, "'"+ref["block_id"].(string)+"'")
+	}
+	l, err := findList("select id,type,content from blocks where id in (" + strings.Join(ids, ",") + ") limit " + strconv.Itoa(maxArticles))
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	for _, b := range l {
+		ret[b["id"].(string)] = &Block{
+			ID:      b["id"].(string),
+			Type:    b["type"].(string),
+			Content: b["content"].(string),
+		}
 	}
 	return ret
 }
 
+// BlockAnchor 返回块在文章页面中的锚点：headingAnchor 为 id 时标题使用带前缀的块ID，其余块使用块ID
+func BlockAnchor(id string, heading bool) string {
+	if heading && config.GetConfig().Hugo.HeadingAnchor == "id" {
+		return config.GetConfig().Hugo.HeadingAnchorPrefix + id
+	}
+	return id
+}
+
+// excerptRadius 为摘录中锚文本前后保留的字数
+const excerptRadius = 80
+
+// excerpt 截取块内容中锚文本附近的文字，转义为 HTML 并使用 <mark> 标记锚文本
+func excerpt(content, anchor string) string {
+	runes := []rune(content)
+	start, end := 0, len(runes)
+	pos := strings.Index(content, anchor)
+	if anchor == "" || pos < 0 {
+		if end > excerptRadius*2 {
+			return html.EscapeString(string(runes[:excerptRadius*2])) + "…"
+		}
+		return html.EscapeString(content)
+	}
+	anchorStart := len([]rune(content[:pos]))
+	anchorEnd := anchorStart + len([]rune(anchor))
+	if anchorStart > excerptRadius {
+		start = anchorStart - excerptRadius
+	}
+	if end-anchorEnd > excerptRadius {
+		end = anchorEnd + excerptRadius
+	}
+	b := strings.Builder{}
+	if start > 0 {
+		b.WriteString("…")
+	}
+	b.WriteString(html.EscapeString(string(runes[start:anchorStart])))
+	b.WriteString("<mark>" + html.EscapeString(anchor) + "</mark>")
+	b.WriteString(html.EscapeString(string(runes[anchorEnd:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+var embedIDRegexp = regexp.MustCompile(`\d{14}-[0-9a-z]{7}`)
+
+// FindEmbedRootIDs 查询文档中嵌入块所嵌入内容所在的文档ID
+func FindEmbedRootIDs(id string) []string {
+	l, err := findList("select markdown from blocks where root_id='" + id + "' and type='query_embed'")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	var ids []string
+	for _, b := range l {
+		for _, eid := range embedIDRegexp.FindAllString(b["markdown"].(string), -1) {
+			ids = append(ids, "'"+eid+"'")
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	l, err = findList("select distinct root_id from blocks where id in (" + strings.Join(ids, ",") + ")")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	ret := make([]string, 0, len(l))
+	for _, b := range l {
+		ret = append(ret, b["root_id"].(string))
+	}
+	return ret
+}
+
+// FindRefRootIDs 查询文档中引用的其他文档ID，包括没有发布的文档
+func FindRefRootIDs(id string) []string {
+	l, err := findList("select distinct def_block_root_id from refs where root_id='" + id + "' and def_block_root_id!='" + id + "'")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	ret := make([]string, 0, len(l))
+	for _, d := range l {
+		ret = append(ret, d["def_block_root_id"].(string))
+	}
+	return ret
+}
+
+// FindSeriesIDs 查询设置了 custom-series=1 属性的文档
+func FindSeriesIDs(ids []string) map[string]bool {
+	ret := make(map[string]bool)
+	if len(ids) == 0 {
+		return ret
+	}
+	quoted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		quoted = append(quoted, "'"+id+"'")
+	}
+	l, err := findList("select block_id from attributes where name='custom-series' and value='1' and block_id in (" + strings.Join(quoted, ",") + ")")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	for _, d := range l {
+		ret[d["block_id"].(string)] = true
+	}
+	return ret
+}
+
+// FindRefBlockIDs 查询文档中被其他块引用的块ID
+func FindRefBlockIDs(id string) map[string]bool {
+	ids, err := findList("select distinct def_block_id from refs where def_block_root_id='" + id + "'")
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	ret := make(map[string]bool)
+	for _, d := range ids {
+		ret[d["def_block_id"].(string)] = true
+	}
+	if config.GetConfig().Hugo.BacklinksContext {
+		// 反链摘录会链接到引用所在的块，需要保留这些块的ID
+		ids, err = findList("select distinct block_id from refs where root_id='" + id + "' and def_block_root_id!='" + id + "'")
+		if err != nil {
+			logger.Fatalf("%+v", errors.WithStack(err))
+		}
+		for _, d := range ids {
+			ret[d["block_id"].(string)] = true
+		}
+	}
+	return ret
+}
+
+// FindBlocks 查询文章中指定类型的块，按块在文档中的顺序排列，无法读取文档文件时按块ID排序
+func FindBlocks(a *Article, typ string) []*Block {
+	l, err := findList("select id,type,subtype,content from blocks where root_id='" + a.ID + "' and type='" + typ + "' order by id limit " + strconv.Itoa(maxArticles))
+	if err != nil {
+		logger.Fatalf("%+v", errors.WithStack(err))
+	}
+	ret := make([]*Block, 0, len(l))
+	for _, b := range l {
+		ret = append(ret, &Block{
+			ID:      b["id"].(string),
+			Type:    b["type"].(string),
+			SubType: b["subtype"].(string),
+			Content: b["content"].(string),
+		})
+	}
+	if order := docBlockOrder(a); order != nil {
+		sort.SliceStable(ret, func(i, j int) bool {
+			return order[ret[i].ID] < order[ret[j].ID]
+		})
+	}
+	return ret
+}
+
+// syNode 为文档 .sy 文件中的节点，只读取块ID和子节点
+type syNode struct {
+	ID       string    `json:"ID"`
+	Children []*syNode `json:"Children"`
+}
+
+// docBlockOrder 读取工作空间中文档的 .sy 文件，返回各个块在文档中的先序位置，读取失败时返回 nil
+func docBlockOrder(a *Article) map[string]int {
+	p := filepath.Join(config.GetConfig().SY.WorkspacePath, "data", a.Box, filepath.FromSlash(a.Path))
+	bs, err := os.ReadFile(p)
+	if err != nil {
+		logger.Warnf("文档文件读取失败，块按ID排序：%s", p)
+		return nil
+	}
+	root := &syNode{}
+	if err = json.Unmarshal(bs, root); err != nil {
+		logger.Warnf("文档文件解析失败，块按ID排序：%s", p)
+		return nil
+	}
+	ret := make(map[string]int)
+	var walk func(n *syNode)
+	walk = func(n *syNode) {
+		if n.ID != "" {
+			ret[n.ID] = len(ret)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+	return ret
+}
+
+// FindAnnotation 从资源文件夹中的 .sya 文件读取 PDF 标注，pdf 为相对于资源文件夹的路径
+func FindAnnotation(pdf, id string) (*Annotation, error) {
+	m, ok := annotations[pdf]
+	if !ok {
+		bs, err := os.ReadFile(filepath.Join(config.GetConfig().SY.AssetsPath, pdf+".sya"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "PDF标注文件读取失败：%s", pdf)
+		}
+		m = make(map[string]*Annotation)
+		if err = json.Unmarshal(bs, &m); err != nil {
+			return nil, errors.Wrapf(err, "PDF标注文件解析失败：%s", pdf)
+		}
+		annotations[pdf] = m
+	}
+	a, ok := m[id]
+	if !ok {
+		return nil, errors.Errorf("未找到PDF标注：%s/%s", pdf, id)
+	}
+	return a, nil
+}
+
+// FindCustomEmojis 查找工作空间 data/emojis 下的自定义表情，返回别名到站点内图片路径的映射。
+// 别名为相对 emojis 文件夹去掉扩展名后的路径，图片发布在站点的 /emojis/ 下。
+func FindCustomEmojis() map[string]string {
+	ret := make(map[string]string)
+	root := config.GetConfig().SY.EmojisPath
+	filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".ico":
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		ret[strings.TrimSuffix(rel, path.Ext(rel))] = "/emojis/" + rel
+		return nil
+	})
+	return ret
+}
+
 func findList(sql string) ([]map[string]any, error) {
 	result := &struct {
 		Result
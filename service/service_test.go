@@ -0,0 +1,71 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"syblog/config"
+	"testing"
+	"time"
+)
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("字", 200)
+	tests := []struct {
+		name    string
+		content string
+		anchor  string
+		want    string
+	}{
+		{"标记锚文本", "参见思源笔记的文档", "思源笔记", "参见<mark>思源笔记</mark>的文档"},
+		{"转义HTML", "a<b> & 锚点", "锚点", "a&lt;b&gt; &amp; <mark>锚点</mark>"},
+		{"没有锚文本", "短内容", "", "短内容"},
+		{"找不到锚文本", "短内容", "其他", "短内容"},
+		{"截断长内容", long, "", strings.Repeat("字", excerptRadius*2) + "…"},
+		{
+			"截取锚文本附近",
+			strings.Repeat("前", 100) + "锚点" + strings.Repeat("后", 100),
+			"锚点",
+			"…" + strings.Repeat("前", excerptRadius) + "<mark>锚点</mark>" + strings.Repeat("后", excerptRadius) + "…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := excerpt(tt.content, tt.anchor); got != tt.want {
+				t.Errorf("excerpt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertAttr(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		ac      config.AttrConfig
+		want    any
+		wantErr bool
+	}{
+		{"字符串", " 值 ", config.AttrConfig{}, "值", false},
+		{"布尔", "true", config.AttrConfig{Type: "bool"}, true, false},
+		{"错误的布尔", "是", config.AttrConfig{Type: "bool"}, nil, true},
+		{"整数", "42", config.AttrConfig{Type: "int"}, int64(42), false},
+		{"浮点数", "1.5", config.AttrConfig{Type: "float"}, 1.5, false},
+		{"列表", "Go, 笔记,,", config.AttrConfig{Type: "list"}, []string{"Go", "笔记"}, false},
+		{"自定义分隔符", "a|b", config.AttrConfig{Type: "list", Separator: "|"}, []string{"a", "b"}, false},
+		{"日期", "2022-03-04", config.AttrConfig{Type: "date"}, time.Date(2022, 3, 4, 0, 0, 0, 0, time.Local), false},
+		{"日期时间", "2022-03-04 05:06", config.AttrConfig{Type: "date"}, time.Date(2022, 3, 4, 5, 6, 0, 0, time.Local), false},
+		{"自定义日期格式", "04/03/2022", config.AttrConfig{Type: "date", Layout: "02/01/2006"}, time.Date(2022, 3, 4, 0, 0, 0, 0, time.Local), false},
+		{"错误的日期", "昨天", config.AttrConfig{Type: "date"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertAttr(tt.value, tt.ac)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertAttr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertAttr() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
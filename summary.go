@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"syblog/config"
+	"syblog/render"
+	"syblog/service"
+	"unicode"
+)
+
+// 阅读速度，中日韩文字按字计算，其他文字按单词计算
+const (
+	cjkPerMinute  = 300
+	wordPerMinute = 200
+)
+
+// setSummaryFrontMatter 将文章的摘要、字数和阅读时间（分钟）输出到 Front Matter 的 summary、wordCount 和 readingTime。
+// 摘要优先使用带有 custom-summary 属性的块，否则截取纯文本的前 summaryLength 个字。
+func setSummaryFrontMatter(article *service.Article, fmMap map[string]any) {
+	if !config.GetConfig().Hugo.Summary {
+		return
+	}
+	text := render.MarkdownText(article.Content)
+	summary := article.Summary
+	if summary == "" {
+		summary = truncate(strings.Join(strings.Fields(text), " "), config.GetConfig().Hugo.SummaryLength)
+	}
+	if summary != "" {
+		fmMap["summary"] = summary
+	}
+	cjk, words := countWords(text)
+	fmMap["wordCount"] = cjk + words
+	fmMap["readingTime"] = int(math.Max(1, math.Ceil(float64(cjk)/cjkPerMinute+float64(words)/wordPerMinute)))
+}
+
+// truncate 截取文本的前 n 个字，截断时末尾加上省略号
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
+
+// countWords 统计文本中中日韩文字的字数和其他文字的单词数
+func countWords(text string) (cjk, words int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return
+}